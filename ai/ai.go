@@ -0,0 +1,874 @@
+// Package ai analyzes video chunks with Google's Gemini models and maps the
+// model output into structured playlist records.
+package ai
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/option"
+
+	"example.com/progress"
+	"example.com/storage"
+	"example.com/video"
+)
+
+// geminiModelName is the multimodal model used for video analysis.
+const geminiModelName = "gemini-1.5-flash"
+
+// Song identifies a piece of music heard in a clip.
+type Song struct {
+	Title  string `json:"title"`
+	Artist string `json:"artist"`
+	Album  string `json:"album,omitempty"`
+}
+
+// URLs holds links to the identified song on streaming services.
+type URLs struct {
+	YouTube string `json:"youtube"`
+	Spotify string `json:"spotify"`
+}
+
+// TokenUsage records how many tokens a single model call consumed, so spend
+// can be tracked per analysis and totalled across a batch.
+type TokenUsage struct {
+	PromptTokens    int32 `json:"prompt_tokens"`
+	CandidateTokens int32 `json:"candidate_tokens"`
+	TotalTokens     int32 `json:"total_tokens"`
+}
+
+// Add accumulates another usage record into u.
+func (u *TokenUsage) Add(other *TokenUsage) {
+	if other == nil {
+		return
+	}
+	u.PromptTokens += other.PromptTokens
+	u.CandidateTokens += other.CandidateTokens
+	u.TotalTokens += other.TotalTokens
+}
+
+// tokenUsageFromMetadata converts the SDK's usage metadata into a TokenUsage.
+// It returns nil when the model reported no metadata.
+func tokenUsageFromMetadata(md *genai.UsageMetadata) *TokenUsage {
+	if md == nil {
+		return nil
+	}
+	return &TokenUsage{
+		PromptTokens:    md.PromptTokenCount,
+		CandidateTokens: md.CandidatesTokenCount,
+		TotalTokens:     md.TotalTokenCount,
+	}
+}
+
+// VideoAnalysisResponse is the structured result of analyzing one video chunk.
+type VideoAnalysisResponse struct {
+	VideoPath     string      `json:"video_path"`
+	Description   string      `json:"description"`
+	Transcript    string      `json:"transcript"`
+	Language      string      `json:"language"` // BCP-47 tag of the spoken or sung language, empty when none
+	HasMusic      bool        `json:"has_music"`
+	Confidence    float64     `json:"confidence"`
+	Song          Song        `json:"song"`
+	WebSearchSong Song        `json:"web_search_song"`
+	URLs          URLs        `json:"urls"`
+	TokenUsage    *TokenUsage `json:"token_usage,omitempty"`
+}
+
+// BestSong returns the single best identification for the clip: the
+// web-search-corroborated song when it has a title, otherwise the model's
+// direct identification. The export layer uses it to collapse the two song
+// columns into one.
+func (r *VideoAnalysisResponse) BestSong() Song {
+	if r.WebSearchSong.Title != "" {
+		return r.WebSearchSong
+	}
+	return r.Song
+}
+
+// Stats tallies one analysis batch for the end-of-run summary. Parse-method
+// counts are an export-side concern (see export.ParseMethodCounts); this
+// covers the typed analysis stream.
+type Stats struct {
+	Total           int // responses counted
+	WithMusic       int // responses with has_music set
+	SongsIdentified int // responses whose best song has a title
+	YouTubeURLs     int // responses carrying a YouTube link
+	SpotifyURLs     int // responses carrying a Spotify link
+}
+
+// Summarize counts a batch of analysis responses, skipping nil entries
+// (failed analyses).
+func Summarize(responses []*VideoAnalysisResponse) Stats {
+	var s Stats
+	for _, r := range responses {
+		if r == nil {
+			continue
+		}
+		s.Total++
+		if r.HasMusic {
+			s.WithMusic++
+		}
+		if r.BestSong().Title != "" {
+			s.SongsIdentified++
+		}
+		if r.URLs.YouTube != "" {
+			s.YouTubeURLs++
+		}
+		if r.URLs.Spotify != "" {
+			s.SpotifyURLs++
+		}
+	}
+	return s
+}
+
+// String renders the one-line batch summary.
+func (s Stats) String() string {
+	return fmt.Sprintf("%d videos analyzed: %d with music, %d songs identified, %d YouTube URLs, %d Spotify URLs",
+		s.Total, s.WithMusic, s.SongsIdentified, s.YouTubeURLs, s.SpotifyURLs)
+}
+
+// AIClient is the interface the analysis pipeline depends on, so tests can
+// substitute MockAIClient for the real Gemini-backed client.
+type AIClient interface {
+	AnalyzeVideo(ctx context.Context, videoPath string) (*VideoAnalysisResponse, error)
+	Close() error
+}
+
+// RemoteAnalyzer is implemented by AI clients that can analyze video
+// content from a stream instead of a local file.
+type RemoteAnalyzer interface {
+	AnalyzeVideoReader(ctx context.Context, r io.Reader, videoPath string) (*VideoAnalysisResponse, error)
+}
+
+// AnalyzeRemoteVideo streams the object behind a gs:// URI from store
+// straight into client's upload, so remote chunks can be analyzed without
+// staging them to disk. client must implement RemoteAnalyzer and store
+// must implement storage.ObjectOpener; the built-in clients do both.
+func AnalyzeRemoteVideo(ctx context.Context, client AIClient, store storage.StorageClient, uri string) (*VideoAnalysisResponse, error) {
+	analyzer, ok := client.(RemoteAnalyzer)
+	if !ok {
+		return nil, fmt.Errorf("the AI client does not support streamed analysis")
+	}
+	opener, ok := store.(storage.ObjectOpener)
+	if !ok {
+		return nil, fmt.Errorf("the storage backend does not support object streaming")
+	}
+	_, key, err := storage.ParseGCSPath(uri)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return nil, fmt.Errorf("path %q does not name an object", uri)
+	}
+	r, err := opener.OpenObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return analyzer.AnalyzeVideoReader(ctx, r, uri)
+}
+
+//go:embed prompts/video_analysis.tmpl
+var defaultVideoAnalysisTemplate string
+
+// VideoAnalysisPromptData is the data available to the video analysis
+// prompt template.
+type VideoAnalysisPromptData struct {
+	VideoPath string
+}
+
+// PromptManager renders analysis prompts from templates so instructions can
+// be tuned without recompiling.
+type PromptManager struct {
+	tmpl *template.Template
+}
+
+// NewPromptManager returns a PromptManager using the built-in
+// video_analysis.tmpl template.
+func NewPromptManager() (*PromptManager, error) {
+	tmpl, err := template.New("video_analysis").Parse(defaultVideoAnalysisTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parsing built-in prompt template: %w", err)
+	}
+	return &PromptManager{tmpl: tmpl}, nil
+}
+
+// NewPromptManagerFromFile returns a PromptManager that renders the template
+// stored at path instead of the built-in one.
+func NewPromptManagerFromFile(path string) (*PromptManager, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading prompt template: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Parse(string(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parsing prompt template %s: %w", path, err)
+	}
+	return &PromptManager{tmpl: tmpl}, nil
+}
+
+// VideoAnalysisPrompt renders the video analysis prompt for the given data.
+func (pm *PromptManager) VideoAnalysisPrompt(data VideoAnalysisPromptData) (string, error) {
+	var sb strings.Builder
+	if err := pm.tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("rendering prompt: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// GeminiAIClient analyzes videos with the Gemini API.
+type GeminiAIClient struct {
+	client  *genai.Client
+	model   *genai.GenerativeModel
+	prompts *PromptManager
+}
+
+// NewGeminiAIClient creates a Gemini-backed client authenticated with apiKey,
+// using the built-in video analysis prompt template.
+func NewGeminiAIClient(ctx context.Context, apiKey string) (*GeminiAIClient, error) {
+	prompts, err := NewPromptManager()
+	if err != nil {
+		return nil, err
+	}
+	return newGeminiAIClient(ctx, apiKey, prompts)
+}
+
+// NewGeminiAIClientWithTemplate is like NewGeminiAIClient but renders prompts
+// from the template file at templatePath, so analysis instructions can be
+// customized without recompiling.
+func NewGeminiAIClientWithTemplate(ctx context.Context, apiKey, templatePath string) (*GeminiAIClient, error) {
+	prompts, err := NewPromptManagerFromFile(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	return newGeminiAIClient(ctx, apiKey, prompts)
+}
+
+func newGeminiAIClient(ctx context.Context, apiKey string, prompts *PromptManager) (*GeminiAIClient, error) {
+	if apiKey == "" {
+		return nil, errors.New("gemini API key is required")
+	}
+	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("creating gemini client: %w", err)
+	}
+	return &GeminiAIClient{
+		client:  client,
+		model:   client.GenerativeModel(geminiModelName),
+		prompts: prompts,
+	}, nil
+}
+
+// videoAnalysisSchema mirrors VideoAnalysisResponse so the model can be asked
+// to emit parseable JSON directly instead of prose.
+var videoAnalysisSchema = &genai.Schema{
+	Type: genai.TypeObject,
+	Properties: map[string]*genai.Schema{
+		"description":     {Type: genai.TypeString},
+		"transcript":      {Type: genai.TypeString},
+		"language":        {Type: genai.TypeString},
+		"has_music":       {Type: genai.TypeBoolean},
+		"confidence":      {Type: genai.TypeNumber},
+		"song":            songSchema,
+		"web_search_song": songSchema,
+		"urls": {
+			Type: genai.TypeObject,
+			Properties: map[string]*genai.Schema{
+				"youtube": {Type: genai.TypeString},
+				"spotify": {Type: genai.TypeString},
+			},
+		},
+	},
+	Required: []string{"description", "has_music"},
+}
+
+// songSchema describes the Song object within videoAnalysisSchema.
+var songSchema = &genai.Schema{
+	Type: genai.TypeObject,
+	Properties: map[string]*genai.Schema{
+		"title":  {Type: genai.TypeString},
+		"artist": {Type: genai.TypeString},
+		"album":  {Type: genai.TypeString},
+	},
+}
+
+// SetStructuredOutput toggles Gemini's structured-output mode, constraining
+// the model to JSON matching videoAnalysisSchema. Not every model supports
+// it, so it is off by default; replies are still run through the relaxed
+// parser either way.
+func (c *GeminiAIClient) SetStructuredOutput(enabled bool) {
+	if enabled {
+		c.model.ResponseMIMEType = "application/json"
+		c.model.ResponseSchema = videoAnalysisSchema
+	} else {
+		c.model.ResponseMIMEType = ""
+		c.model.ResponseSchema = nil
+	}
+}
+
+// AnalyzeVideo uploads the video at videoPath to the Gemini File API, asks the
+// model to analyze it, and parses the reply into a VideoAnalysisResponse.
+func (c *GeminiAIClient) AnalyzeVideo(ctx context.Context, videoPath string) (*VideoAnalysisResponse, error) {
+	f, err := os.Open(videoPath)
+	if err != nil {
+		return nil, fmt.Errorf("opening video: %w", err)
+	}
+	defer f.Close()
+	return c.AnalyzeVideoReader(ctx, f, videoPath)
+}
+
+// AnalyzeVideoReader is like AnalyzeVideo but streams the video content
+// from r, so remote objects can be analyzed without staging them to disk.
+// videoPath only labels the result and picks the upload MIME type.
+func (c *GeminiAIClient) AnalyzeVideoReader(ctx context.Context, r io.Reader, videoPath string) (*VideoAnalysisResponse, error) {
+	file, err := c.client.UploadFile(ctx, "", r, &genai.UploadFileOptions{
+		MIMEType: mimeTypeForFile(videoPath),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("uploading %s: %w", videoPath, err)
+	}
+	defer c.client.DeleteFile(ctx, file.Name)
+
+	file, err = c.waitForFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt, err := c.prompts.VideoAnalysisPrompt(VideoAnalysisPromptData{VideoPath: videoPath})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.model.GenerateContent(ctx,
+		genai.FileData{URI: file.URI},
+		genai.Text(prompt),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("analyzing %s: %w", videoPath, err)
+	}
+
+	result, err := parseAnalysisResponse(responseText(resp))
+	if err != nil {
+		return nil, fmt.Errorf("parsing analysis of %s: %w", videoPath, err)
+	}
+	result.VideoPath = videoPath
+	result.TokenUsage = tokenUsageFromMetadata(resp.UsageMetadata)
+	return result, nil
+}
+
+// waitForFile polls the File API until the uploaded file is ready for use.
+func (c *GeminiAIClient) waitForFile(ctx context.Context, file *genai.File) (*genai.File, error) {
+	for file.State == genai.FileStateProcessing {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+		var err error
+		file, err = c.client.GetFile(ctx, file.Name)
+		if err != nil {
+			return nil, fmt.Errorf("polling uploaded file: %w", err)
+		}
+	}
+	if file.State != genai.FileStateActive {
+		return nil, fmt.Errorf("uploaded file entered state %v", file.State)
+	}
+	return file, nil
+}
+
+// Close releases the underlying Gemini client.
+func (c *GeminiAIClient) Close() error {
+	return c.client.Close()
+}
+
+// responseText concatenates the text parts of the first candidate.
+func responseText(resp *genai.GenerateContentResponse) string {
+	var sb strings.Builder
+	if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
+		for _, part := range resp.Candidates[0].Content.Parts {
+			if text, ok := part.(genai.Text); ok {
+				sb.WriteString(string(text))
+			}
+		}
+	}
+	return sb.String()
+}
+
+// parseAnalysisResponse unmarshals the model's reply, tolerating a leading or
+// trailing markdown code fence.
+func parseAnalysisResponse(text string) (*VideoAnalysisResponse, error) {
+	trimmed := strings.TrimSpace(text)
+	trimmed = strings.TrimPrefix(trimmed, "```json")
+	trimmed = strings.TrimPrefix(trimmed, "```")
+	trimmed = strings.TrimSuffix(trimmed, "```")
+	var result VideoAnalysisResponse
+	if err := json.Unmarshal([]byte(strings.TrimSpace(trimmed)), &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// mimeTypeForFile guesses a video MIME type from the file extension.
+func mimeTypeForFile(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mp4":
+		return "video/mp4"
+	case ".mov":
+		return "video/quicktime"
+	case ".webm":
+		return "video/webm"
+	case ".mkv":
+		return "video/x-matroska"
+	case ".avi":
+		return "video/x-msvideo"
+	default:
+		return "video/mp4"
+	}
+}
+
+// MockAIClient is an AIClient test double that records calls and returns a
+// canned response.
+type MockAIClient struct {
+	mu sync.Mutex
+
+	// Response is the template returned from AnalyzeVideo with VideoPath
+	// filled in per call. When nil a minimal response is synthesized.
+	Response *VideoAnalysisResponse
+	// Err, when set, is returned from every AnalyzeVideo call.
+	Err error
+	// DelayByPath makes AnalyzeVideo for a matching path block for the
+	// configured duration (or until the context is canceled).
+	DelayByPath map[string]time.Duration
+
+	AnalyzeCount  int
+	AnalyzedPaths []string
+}
+
+// NewMockAIClient returns an empty mock.
+func NewMockAIClient() *MockAIClient {
+	return &MockAIClient{}
+}
+
+// AnalyzeVideoReader implements RemoteAnalyzer: it drains r and otherwise
+// behaves like AnalyzeVideo.
+func (m *MockAIClient) AnalyzeVideoReader(ctx context.Context, r io.Reader, videoPath string) (*VideoAnalysisResponse, error) {
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return nil, err
+	}
+	return m.AnalyzeVideo(ctx, videoPath)
+}
+
+// AnalyzeVideo records the call and returns the configured canned response.
+func (m *MockAIClient) AnalyzeVideo(ctx context.Context, videoPath string) (*VideoAnalysisResponse, error) {
+	m.mu.Lock()
+	m.AnalyzeCount++
+	m.AnalyzedPaths = append(m.AnalyzedPaths, videoPath)
+	m.mu.Unlock()
+
+	if delay := m.DelayByPath[videoPath]; delay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	resp := VideoAnalysisResponse{
+		Description: "mock analysis",
+	}
+	if m.Response != nil {
+		resp = *m.Response
+	}
+	resp.VideoPath = videoPath
+	return &resp, nil
+}
+
+// Close implements AIClient.
+func (m *MockAIClient) Close() error {
+	return nil
+}
+
+// AnalysisManager runs an AIClient over batches of videos with bounded
+// concurrency.
+type AnalysisManager struct {
+	client      AIClient
+	concurrency int
+	timeout     time.Duration
+
+	prober      VideoProber
+	maxDuration time.Duration
+	maxBytes    int64
+	remuxer     Remuxer
+
+	checkpointMu   sync.Mutex
+	checkpointFile *os.File
+	checkpointDone map[string]bool
+
+	mu          sync.Mutex
+	tokenTotals TokenUsage
+}
+
+// VideoProber reports local video metadata; it is satisfied by
+// *video.VideoProcessor and faked in tests.
+type VideoProber interface {
+	GetVideoInfo(path string) (*video.VideoInfo, error)
+}
+
+// Remuxer rewrites a source file into an mp4 container, reporting whether a
+// lossless stream copy was possible. It is satisfied by
+// *video.VideoProcessor.
+type Remuxer interface {
+	Remux(inputFile, outputFile string) (bool, error)
+}
+
+// defaultAnalysisConcurrency bounds how many videos are analyzed in parallel.
+const defaultAnalysisConcurrency = 4
+
+// defaultAnalysisTimeout bounds a single AnalyzeVideo call so one stuck
+// request cannot hang a batch indefinitely.
+const defaultAnalysisTimeout = 2 * time.Minute
+
+// NewAnalysisManager creates a manager that analyzes videos with client.
+func NewAnalysisManager(client AIClient) *AnalysisManager {
+	return &AnalysisManager{
+		client:      client,
+		concurrency: defaultAnalysisConcurrency,
+		timeout:     defaultAnalysisTimeout,
+	}
+}
+
+// SetTimeout overrides the per-video analysis timeout. A zero or negative
+// duration disables the deadline entirely.
+func (m *AnalysisManager) SetTimeout(d time.Duration) {
+	m.timeout = d
+}
+
+// SetConcurrency bounds how many videos are analyzed in parallel. Values
+// below 1 are clamped to 1.
+func (m *AnalysisManager) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	m.concurrency = n
+}
+
+// ErrQuotaExhausted marks a hard quota-exceeded failure from the model API.
+// Unlike a transient rate limit, retrying cannot succeed until the quota
+// resets, so a batch aborts as soon as one analysis reports it.
+var ErrQuotaExhausted = errors.New("api quota exhausted")
+
+// isQuotaExhausted reports whether err is hard quota exhaustion rather than
+// transient throttling. Gemini reports exhaustion as a RESOURCE_EXHAUSTED
+// status naming the exceeded quota.
+func isQuotaExhausted(err error) bool {
+	if errors.Is(err, ErrQuotaExhausted) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "RESOURCE_EXHAUSTED") && strings.Contains(strings.ToLower(msg), "quota")
+}
+
+// SetPreflight installs a pre-upload check that rejects videos longer than
+// maxDuration or larger than maxBytes before any API call is made. Either
+// limit can be zero to skip that check.
+func (m *AnalysisManager) SetPreflight(prober VideoProber, maxDuration time.Duration, maxBytes int64) {
+	m.prober = prober
+	m.maxDuration = maxDuration
+	m.maxBytes = maxBytes
+}
+
+// SetRemux installs a remuxer used to convert non-mp4 inputs into mp4
+// before they are sent for analysis, so odd containers don't have to be
+// converted by hand first.
+func (m *AnalysisManager) SetRemux(r Remuxer) {
+	m.remuxer = r
+}
+
+// remuxToMP4 rewrites path into a temporary mp4 the model will accept and
+// returns its location; the caller removes the file when done.
+func (m *AnalysisManager) remuxToMP4(path string) (string, error) {
+	tmp, err := os.CreateTemp("", "remux-*.mp4")
+	if err != nil {
+		return "", err
+	}
+	tmp.Close()
+	copied, err := m.remuxer.Remux(path, tmp.Name())
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if copied {
+		slog.Info("remuxed input to mp4", "path", path)
+	} else {
+		slog.Info("transcoded input to mp4", "path", path)
+	}
+	return tmp.Name(), nil
+}
+
+// SetCheckpoint enables resumable batches: each successfully analyzed path is
+// appended to the file at path once its result has been written, and paths
+// already recorded there are skipped on later runs. The checkpoint entry is
+// written after the result, so an interrupted run never marks a video done
+// without its output.
+func (m *AnalysisManager) SetCheckpoint(path string) error {
+	done := make(map[string]bool)
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("reading checkpoint %s: %w", path, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			done[line] = true
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening checkpoint %s: %w", path, err)
+	}
+	m.checkpointMu.Lock()
+	defer m.checkpointMu.Unlock()
+	if m.checkpointFile != nil {
+		m.checkpointFile.Close()
+	}
+	m.checkpointFile = f
+	m.checkpointDone = done
+	return nil
+}
+
+// checkpointed reports whether path completed in an earlier run.
+func (m *AnalysisManager) checkpointed(path string) bool {
+	return m.checkpointDone[path]
+}
+
+// recordCheckpoint appends one completed path to the checkpoint file.
+func (m *AnalysisManager) recordCheckpoint(path string) error {
+	m.checkpointMu.Lock()
+	defer m.checkpointMu.Unlock()
+	if m.checkpointFile == nil {
+		return nil
+	}
+	if _, err := fmt.Fprintln(m.checkpointFile, path); err != nil {
+		return err
+	}
+	return m.checkpointFile.Sync()
+}
+
+// preflight validates one video against the configured limits and returns
+// the probed metadata, or nil when no prober is configured.
+func (m *AnalysisManager) preflight(path string) (*video.VideoInfo, error) {
+	if m.prober == nil {
+		return nil, nil
+	}
+	info, err := m.prober.GetVideoInfo(path)
+	if err != nil {
+		return nil, fmt.Errorf("preflight probe of %s: %w", path, err)
+	}
+	if m.maxDuration > 0 && info.Duration > m.maxDuration {
+		return nil, fmt.Errorf("%s is %s long, exceeding the %s analysis limit", path, info.Duration, m.maxDuration)
+	}
+	if m.maxBytes > 0 && info.Size > m.maxBytes {
+		return nil, fmt.Errorf("%s is %d bytes, exceeding the %d byte analysis limit", path, info.Size, m.maxBytes)
+	}
+	return info, nil
+}
+
+// AnalyzeVideos analyzes every path with bounded concurrency. Failures are
+// logged and do not stop the batch; the returned slice holds the successful
+// results in input order and the returned error joins any per-video errors.
+func (m *AnalysisManager) AnalyzeVideos(ctx context.Context, videoPaths []string) ([]*VideoAnalysisResponse, error) {
+	results, err := m.analyzeAll(ctx, videoPaths, nil)
+	completed := make([]*VideoAnalysisResponse, 0, len(results))
+	for _, r := range results {
+		if r != nil {
+			completed = append(completed, r)
+		}
+	}
+	return completed, err
+}
+
+// AnalyzeVideosStream is like AnalyzeVideos but additionally writes each
+// result as a JSON line to w the moment it is produced, so partial output
+// survives a crash and large batches don't have to accumulate in memory.
+// Lines are written in completion order, not input order.
+func (m *AnalysisManager) AnalyzeVideosStream(ctx context.Context, videoPaths []string, w io.Writer) error {
+	var writeMu sync.Mutex
+	enc := json.NewEncoder(w)
+	_, err := m.analyzeAll(ctx, videoPaths, func(resp *VideoAnalysisResponse) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		return enc.Encode(resp)
+	})
+	return err
+}
+
+// analyzeAll runs the worker pool over videoPaths, invoking onResult (when
+// non-nil) for each successful analysis as it completes. The returned slice
+// is indexed by input position with nil entries for failures.
+func (m *AnalysisManager) analyzeAll(ctx context.Context, videoPaths []string, onResult func(*VideoAnalysisResponse) error) ([]*VideoAnalysisResponse, error) {
+	results := make([]*VideoAnalysisResponse, len(videoPaths))
+	errs := make([]error, len(videoPaths))
+	skipped := make([]bool, len(videoPaths))
+
+	// abort lets any worker stop the whole batch (e.g. on quota
+	// exhaustion); already-finished results are kept so partial output
+	// can be flushed.
+	ctx, abort := context.WithCancel(ctx)
+	defer abort()
+
+	sem := make(chan struct{}, m.concurrency)
+	eta := progress.NewETA(len(videoPaths), 0)
+	var wg sync.WaitGroup
+	for i, path := range videoPaths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			if err := ctx.Err(); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", path, err)
+				return
+			}
+			if m.checkpointed(path) {
+				slog.Info("skipping checkpointed video", "path", path)
+				skipped[i] = true
+				return
+			}
+
+			info, err := m.preflight(path)
+			if err != nil {
+				slog.Error("video rejected by preflight", "path", path, "error", err)
+				errs[i] = err
+				return
+			}
+			if info != nil && !info.HasAudio {
+				// Nothing to transcribe or identify; record the video
+				// without spending an API call.
+				slog.Info("video has no audio stream, skipping analysis", "path", path)
+				resp := &VideoAnalysisResponse{VideoPath: path, HasMusic: false}
+				if onResult != nil {
+					if err := onResult(resp); err != nil {
+						errs[i] = fmt.Errorf("writing result for %s: %w", path, err)
+						return
+					}
+				}
+				if err := m.recordCheckpoint(path); err != nil {
+					errs[i] = fmt.Errorf("checkpointing %s: %w", path, err)
+					return
+				}
+				results[i] = resp
+				return
+			}
+
+			analyzePath := path
+			if m.remuxer != nil && strings.ToLower(filepath.Ext(path)) != ".mp4" {
+				converted, err := m.remuxToMP4(path)
+				if err != nil {
+					slog.Error("remux to mp4 failed", "path", path, "error", err)
+					errs[i] = fmt.Errorf("remuxing %s: %w", path, err)
+					return
+				}
+				defer os.Remove(converted)
+				analyzePath = converted
+			}
+
+			slog.Info("analyzing video", "path", path)
+			callCtx := ctx
+			if m.timeout > 0 {
+				var cancel context.CancelFunc
+				callCtx, cancel = context.WithTimeout(ctx, m.timeout)
+				defer cancel()
+			}
+			analysisStart := time.Now()
+			resp, err := m.client.AnalyzeVideo(callCtx, analyzePath)
+			if err != nil {
+				slog.Error("video analysis failed", "path", path, "error", err)
+				errs[i] = fmt.Errorf("%s: %w", path, err)
+				if isQuotaExhausted(err) {
+					slog.Error("api quota exhausted, aborting batch", "path", path)
+					abort()
+				}
+				return
+			}
+			if left := eta.Record(time.Since(analysisStart)); left > 0 {
+				slog.Info("video analyzed", "path", path, "eta", left.Round(time.Second))
+			}
+			if analyzePath != path {
+				// Records should name the source file, not the
+				// temporary mp4.
+				resp.VideoPath = path
+			}
+			m.recordUsage(resp.TokenUsage)
+			if onResult != nil {
+				if err := onResult(resp); err != nil {
+					errs[i] = fmt.Errorf("writing result for %s: %w", path, err)
+					return
+				}
+			}
+			if err := m.recordCheckpoint(path); err != nil {
+				errs[i] = fmt.Errorf("checkpointing %s: %w", path, err)
+				return
+			}
+			results[i] = resp
+		}(i, path)
+	}
+	wg.Wait()
+
+	completed, skippedCount := 0, 0
+	for i, r := range results {
+		if r != nil {
+			completed++
+		}
+		if skipped[i] {
+			skippedCount++
+		}
+	}
+	totals := m.TokenTotals()
+	slog.Info("batch analysis complete",
+		"videos", completed,
+		"skipped", skippedCount,
+		"failed", len(videoPaths)-completed-skippedCount,
+		"prompt_tokens", totals.PromptTokens,
+		"candidate_tokens", totals.CandidateTokens,
+		"total_tokens", totals.TotalTokens,
+	)
+	return results, errors.Join(errs...)
+}
+
+// recordUsage adds one analysis's token usage to the batch running totals.
+func (m *AnalysisManager) recordUsage(usage *TokenUsage) {
+	if usage == nil {
+		return
+	}
+	m.mu.Lock()
+	m.tokenTotals.Add(usage)
+	totals := m.tokenTotals
+	m.mu.Unlock()
+	slog.Info("token usage",
+		"prompt_tokens", usage.PromptTokens,
+		"candidate_tokens", usage.CandidateTokens,
+		"total_tokens", usage.TotalTokens,
+		"batch_total_tokens", totals.TotalTokens,
+	)
+}
+
+// TokenTotals reports the accumulated token usage across all analyses this
+// manager has run.
+func (m *AnalysisManager) TokenTotals() TokenUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.tokenTotals
+}
@@ -0,0 +1,475 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/generative-ai-go/genai"
+
+	"example.com/storage"
+	"example.com/video"
+)
+
+func TestTokenUsageFromMetadata(t *testing.T) {
+	usage := tokenUsageFromMetadata(&genai.UsageMetadata{
+		PromptTokenCount:     120,
+		CandidatesTokenCount: 45,
+		TotalTokenCount:      165,
+	})
+	if usage == nil {
+		t.Fatal("tokenUsageFromMetadata returned nil for populated metadata")
+	}
+	if usage.PromptTokens != 120 || usage.CandidateTokens != 45 || usage.TotalTokens != 165 {
+		t.Errorf("got %+v, want {120 45 165}", *usage)
+	}
+
+	if got := tokenUsageFromMetadata(nil); got != nil {
+		t.Errorf("tokenUsageFromMetadata(nil) = %+v, want nil", got)
+	}
+}
+
+func TestGeminiAIClientRendersPromptFromTemplate(t *testing.T) {
+	dir := t.TempDir()
+	templatePath := filepath.Join(dir, "custom.tmpl")
+	if err := os.WriteFile(templatePath, []byte("Custom instructions for {{.VideoPath}}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewGeminiAIClientWithTemplate(context.Background(), "test-key", templatePath)
+	if err != nil {
+		t.Fatalf("NewGeminiAIClientWithTemplate: %v", err)
+	}
+	defer client.Close()
+
+	prompt, err := client.prompts.VideoAnalysisPrompt(VideoAnalysisPromptData{VideoPath: "clip.mp4"})
+	if err != nil {
+		t.Fatalf("VideoAnalysisPrompt: %v", err)
+	}
+	if prompt != "Custom instructions for clip.mp4" {
+		t.Errorf("rendered prompt = %q, want custom template output", prompt)
+	}
+}
+
+func TestGeminiAIClientStructuredOutput(t *testing.T) {
+	client, err := NewGeminiAIClient(context.Background(), "test-key")
+	if err != nil {
+		t.Fatalf("NewGeminiAIClient: %v", err)
+	}
+	defer client.Close()
+
+	client.SetStructuredOutput(true)
+	if client.model.ResponseMIMEType != "application/json" {
+		t.Errorf("ResponseMIMEType = %q, want application/json", client.model.ResponseMIMEType)
+	}
+	if client.model.ResponseSchema == nil {
+		t.Fatal("ResponseSchema not set")
+	}
+	if _, ok := client.model.ResponseSchema.Properties["has_music"]; !ok {
+		t.Error("ResponseSchema is missing has_music")
+	}
+
+	client.SetStructuredOutput(false)
+	if client.model.ResponseMIMEType != "" || client.model.ResponseSchema != nil {
+		t.Error("disabling structured output should reset the model configuration")
+	}
+}
+
+func TestDefaultPromptManagerUsesEmbeddedTemplate(t *testing.T) {
+	pm, err := NewPromptManager()
+	if err != nil {
+		t.Fatalf("NewPromptManager: %v", err)
+	}
+	prompt, err := pm.VideoAnalysisPrompt(VideoAnalysisPromptData{VideoPath: "clip.mp4"})
+	if err != nil {
+		t.Fatalf("VideoAnalysisPrompt: %v", err)
+	}
+	if !strings.Contains(prompt, "JSON object") {
+		t.Errorf("default prompt missing expected instructions: %q", prompt)
+	}
+}
+
+func TestAnalyzeVideosTimesOutSlowVideoOnly(t *testing.T) {
+	mock := NewMockAIClient()
+	mock.DelayByPath = map[string]time.Duration{
+		"slow.mp4": time.Second,
+	}
+
+	manager := NewAnalysisManager(mock)
+	manager.SetTimeout(20 * time.Millisecond)
+
+	results, err := manager.AnalyzeVideos(context.Background(), []string{"fast.mp4", "slow.mp4", "also-fast.mp4"})
+	if err == nil {
+		t.Fatal("expected a timeout error for the slow video")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2 (the fast videos)", len(results))
+	}
+	for _, r := range results {
+		if r.VideoPath == "slow.mp4" {
+			t.Errorf("slow video unexpectedly produced a result")
+		}
+	}
+}
+
+func TestAnalyzeVideosStreamWritesOneJSONLinePerVideo(t *testing.T) {
+	mock := NewMockAIClient()
+	mock.Response = &VideoAnalysisResponse{Description: "streamed", HasMusic: true}
+
+	manager := NewAnalysisManager(mock)
+	var buf bytes.Buffer
+	paths := []string{"a.mp4", "b.mp4", "c.mp4"}
+	if err := manager.AnalyzeVideosStream(context.Background(), paths, &buf); err != nil {
+		t.Fatalf("AnalyzeVideosStream: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(paths) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(paths))
+	}
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		var resp VideoAnalysisResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("line %q is not valid JSON: %v", line, err)
+		}
+		if resp.Description != "streamed" {
+			t.Errorf("line %q missing canned description", line)
+		}
+		seen[resp.VideoPath] = true
+	}
+	for _, p := range paths {
+		if !seen[p] {
+			t.Errorf("no line written for %s", p)
+		}
+	}
+}
+
+func TestBestSong(t *testing.T) {
+	direct := Song{Title: "Heard Title", Artist: "Heard Artist"}
+	confirmed := Song{Title: "Confirmed Title", Artist: "Confirmed Artist"}
+
+	tests := []struct {
+		name string
+		resp VideoAnalysisResponse
+		want Song
+	}{
+		{"both populated prefers web search", VideoAnalysisResponse{Song: direct, WebSearchSong: confirmed}, confirmed},
+		{"web search empty falls back", VideoAnalysisResponse{Song: direct}, direct},
+		{"only web search", VideoAnalysisResponse{WebSearchSong: confirmed}, confirmed},
+		{"both empty", VideoAnalysisResponse{}, Song{}},
+		{"web search artist without title falls back", VideoAnalysisResponse{Song: direct, WebSearchSong: Song{Artist: "Someone"}}, direct},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.resp.BestSong(); got != tt.want {
+				t.Errorf("BestSong() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeProber returns fixed metadata for every path.
+type fakeProber struct {
+	info *video.VideoInfo
+	err  error
+}
+
+func (f *fakeProber) GetVideoInfo(path string) (*video.VideoInfo, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	info := *f.info
+	info.Path = path
+	return &info, nil
+}
+
+func TestPreflightRejectsOversizedVideos(t *testing.T) {
+	mock := NewMockAIClient()
+	manager := NewAnalysisManager(mock)
+	manager.SetPreflight(&fakeProber{info: &video.VideoInfo{
+		Duration: 10 * time.Minute,
+		Size:     500 << 20,
+	}}, 30*time.Second, 100<<20)
+
+	_, err := manager.AnalyzeVideos(context.Background(), []string{"huge.mp4"})
+	if err == nil {
+		t.Fatal("expected a preflight error for an oversized video")
+	}
+	if !strings.Contains(err.Error(), "exceeding") {
+		t.Errorf("error %q does not describe the exceeded limit", err)
+	}
+	if mock.AnalyzeCount != 0 {
+		t.Errorf("AnalyzeVideo was called %d times despite failed preflight", mock.AnalyzeCount)
+	}
+}
+
+func TestPreflightAllowsVideosWithinLimits(t *testing.T) {
+	mock := NewMockAIClient()
+	manager := NewAnalysisManager(mock)
+	manager.SetPreflight(&fakeProber{info: &video.VideoInfo{
+		Duration: 20 * time.Second,
+		Size:     1 << 20,
+		HasAudio: true,
+	}}, 30*time.Second, 100<<20)
+
+	results, err := manager.AnalyzeVideos(context.Background(), []string{"small.mp4"})
+	if err != nil {
+		t.Fatalf("AnalyzeVideos: %v", err)
+	}
+	if len(results) != 1 || mock.AnalyzeCount != 1 {
+		t.Errorf("got %d results and %d calls, want 1 and 1", len(results), mock.AnalyzeCount)
+	}
+}
+
+func TestAnalyzeVideosAccumulatesTokenUsage(t *testing.T) {
+	mock := NewMockAIClient()
+	mock.Response = &VideoAnalysisResponse{
+		Description: "test clip",
+		TokenUsage: &TokenUsage{
+			PromptTokens:    100,
+			CandidateTokens: 20,
+			TotalTokens:     120,
+		},
+	}
+
+	manager := NewAnalysisManager(mock)
+	paths := []string{"a.mp4", "b.mp4", "c.mp4"}
+	results, err := manager.AnalyzeVideos(context.Background(), paths)
+	if err != nil {
+		t.Fatalf("AnalyzeVideos: %v", err)
+	}
+	if len(results) != len(paths) {
+		t.Fatalf("got %d results, want %d", len(results), len(paths))
+	}
+	for _, r := range results {
+		if r.TokenUsage == nil || r.TokenUsage.TotalTokens != 120 {
+			t.Errorf("result for %s missing token usage: %+v", r.VideoPath, r.TokenUsage)
+		}
+	}
+
+	totals := manager.TokenTotals()
+	if totals.PromptTokens != 300 || totals.CandidateTokens != 60 || totals.TotalTokens != 360 {
+		t.Errorf("batch totals = %+v, want {300 60 360}", totals)
+	}
+}
+
+func TestAnalysisManagerCheckpointResume(t *testing.T) {
+	checkpoint := filepath.Join(t.TempDir(), "checkpoint.txt")
+	paths := []string{"a.mp4", "b.mp4", "c.mp4"}
+
+	// Simulate a run that was interrupted after completing the first video.
+	if err := os.WriteFile(checkpoint, []byte("a.mp4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := NewMockAIClient()
+	manager := NewAnalysisManager(mock)
+	if err := manager.SetCheckpoint(checkpoint); err != nil {
+		t.Fatalf("SetCheckpoint: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := manager.AnalyzeVideosStream(context.Background(), paths, &buf); err != nil {
+		t.Fatalf("AnalyzeVideosStream: %v", err)
+	}
+
+	if mock.AnalyzeCount != 2 {
+		t.Errorf("AnalyzeCount = %d, want 2 (a.mp4 already checkpointed)", mock.AnalyzeCount)
+	}
+	for _, path := range mock.AnalyzedPaths {
+		if path == "a.mp4" {
+			t.Error("a.mp4 was re-analyzed despite the checkpoint")
+		}
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 2 {
+		t.Errorf("got %d NDJSON lines, want 2", lines)
+	}
+
+	data, err := os.ReadFile(checkpoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		if !strings.Contains(string(data), path) {
+			t.Errorf("checkpoint is missing %s:\n%s", path, data)
+		}
+	}
+
+	// A fully checkpointed rerun analyzes nothing.
+	resumed := NewMockAIClient()
+	manager = NewAnalysisManager(resumed)
+	if err := manager.SetCheckpoint(checkpoint); err != nil {
+		t.Fatalf("SetCheckpoint: %v", err)
+	}
+	if err := manager.AnalyzeVideosStream(context.Background(), paths, &buf); err != nil {
+		t.Fatalf("resumed AnalyzeVideosStream: %v", err)
+	}
+	if resumed.AnalyzeCount != 0 {
+		t.Errorf("AnalyzeCount = %d after full checkpoint, want 0", resumed.AnalyzeCount)
+	}
+}
+
+func TestSummarizeCountsMixedBatch(t *testing.T) {
+	responses := []*VideoAnalysisResponse{
+		{
+			VideoPath: "chunk_001.mp4",
+			HasMusic:  true,
+			Song:      Song{Title: "Hey Jude", Artist: "The Beatles"},
+			URLs:      URLs{YouTube: "https://youtube.com/watch?v=1", Spotify: "https://open.spotify.com/track/1"},
+		},
+		{
+			VideoPath:     "chunk_002.mp4",
+			HasMusic:      true,
+			WebSearchSong: Song{Title: "Let It Be", Artist: "The Beatles"},
+			URLs:          URLs{YouTube: "https://youtube.com/watch?v=2"},
+		},
+		{VideoPath: "chunk_003.mp4", HasMusic: true},
+		{VideoPath: "chunk_004.mp4"},
+		nil, // failed analysis
+	}
+
+	got := Summarize(responses)
+	want := Stats{Total: 4, WithMusic: 3, SongsIdentified: 2, YouTubeURLs: 2, SpotifyURLs: 1}
+	if got != want {
+		t.Errorf("Summarize = %+v, want %+v", got, want)
+	}
+
+	line := got.String()
+	for _, fragment := range []string{"4 videos analyzed", "3 with music", "2 songs identified", "2 YouTube URLs", "1 Spotify URLs"} {
+		if !strings.Contains(line, fragment) {
+			t.Errorf("summary %q missing %q", line, fragment)
+		}
+	}
+}
+
+func TestQuotaExhaustionAbortsBatch(t *testing.T) {
+	mock := NewMockAIClient()
+	mock.Err = fmt.Errorf("googleapi: Error 429: RESOURCE_EXHAUSTED: Quota exceeded for generate requests: %w", ErrQuotaExhausted)
+
+	manager := NewAnalysisManager(mock)
+	manager.SetConcurrency(1)
+
+	paths := []string{"a.mp4", "b.mp4", "c.mp4", "d.mp4"}
+	var buf bytes.Buffer
+	err := manager.AnalyzeVideosStream(context.Background(), paths, &buf)
+	if err == nil {
+		t.Fatal("expected the quota error to surface")
+	}
+	if !errors.Is(err, ErrQuotaExhausted) {
+		t.Errorf("error = %v, want ErrQuotaExhausted", err)
+	}
+	if mock.AnalyzeCount != 1 {
+		t.Errorf("AnalyzeCount = %d, want 1: the batch should stop after the exhausted call", mock.AnalyzeCount)
+	}
+
+	// A plain transient failure must not abort the rest of the batch.
+	mock = NewMockAIClient()
+	mock.Err = fmt.Errorf("googleapi: Error 429: rate limit, slow down")
+	manager = NewAnalysisManager(mock)
+	manager.SetConcurrency(1)
+	if err := manager.AnalyzeVideosStream(context.Background(), paths, &buf); err == nil {
+		t.Fatal("expected the transient errors to surface")
+	}
+	if mock.AnalyzeCount != len(paths) {
+		t.Errorf("AnalyzeCount = %d, want %d: throttling should not abort the batch", mock.AnalyzeCount, len(paths))
+	}
+}
+
+func TestAnalyzeRemoteVideoStreamsFromStorage(t *testing.T) {
+	store := storage.NewMockStorageClient()
+	store.ObjectData = map[string][]byte{
+		"chunks/chunk_001.mp4": []byte("fake video bytes"),
+	}
+	client := NewMockAIClient()
+
+	resp, err := AnalyzeRemoteVideo(context.Background(), client, store, "gs://test-bucket/chunks/chunk_001.mp4")
+	if err != nil {
+		t.Fatalf("AnalyzeRemoteVideo: %v", err)
+	}
+	if resp.VideoPath != "gs://test-bucket/chunks/chunk_001.mp4" {
+		t.Errorf("VideoPath = %q, want the gs:// URI", resp.VideoPath)
+	}
+	if len(store.OpenedObjects) != 1 || store.OpenedObjects[0] != "chunks/chunk_001.mp4" {
+		t.Errorf("OpenedObjects = %v, want the object key", store.OpenedObjects)
+	}
+	if len(client.AnalyzedPaths) != 1 || client.AnalyzedPaths[0] != "gs://test-bucket/chunks/chunk_001.mp4" {
+		t.Errorf("AnalyzedPaths = %v, want the gs:// URI", client.AnalyzedPaths)
+	}
+
+	if _, err := AnalyzeRemoteVideo(context.Background(), client, store, "gs://test-bucket"); err == nil {
+		t.Error("expected an error for a URI without an object key")
+	}
+	if _, err := AnalyzeRemoteVideo(context.Background(), client, store, "gs://test-bucket/missing.mp4"); err == nil {
+		t.Error("expected an error when the object cannot be opened")
+	}
+}
+
+func TestSilentVideosSkipAnalysis(t *testing.T) {
+	mock := NewMockAIClient()
+	manager := NewAnalysisManager(mock)
+	manager.SetPreflight(&fakeProber{info: &video.VideoInfo{
+		Duration: 20 * time.Second,
+		Size:     1 << 20,
+	}}, 30*time.Second, 100<<20)
+
+	results, err := manager.AnalyzeVideos(context.Background(), []string{"silent.mp4"})
+	if err != nil {
+		t.Fatalf("AnalyzeVideos: %v", err)
+	}
+	if mock.AnalyzeCount != 0 {
+		t.Errorf("AnalyzeVideo was called %d times for an audio-less video", mock.AnalyzeCount)
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	if results[0].VideoPath != "silent.mp4" || results[0].HasMusic {
+		t.Errorf("result = %+v, want silent.mp4 with HasMusic false", results[0])
+	}
+}
+
+// fakeRemuxer records remux requests without running ffmpeg.
+type fakeRemuxer struct {
+	inputs []string
+}
+
+func (f *fakeRemuxer) Remux(inputFile, outputFile string) (bool, error) {
+	f.inputs = append(f.inputs, inputFile)
+	return true, nil
+}
+
+func TestNonMP4InputsAreRemuxedBeforeAnalysis(t *testing.T) {
+	mock := NewMockAIClient()
+	manager := NewAnalysisManager(mock)
+	remuxer := &fakeRemuxer{}
+	manager.SetRemux(remuxer)
+	manager.SetConcurrency(1)
+
+	results, err := manager.AnalyzeVideos(context.Background(), []string{"clip.mov", "clip.mp4"})
+	if err != nil {
+		t.Fatalf("AnalyzeVideos: %v", err)
+	}
+	if len(remuxer.inputs) != 1 || remuxer.inputs[0] != "clip.mov" {
+		t.Errorf("remuxed inputs = %v, want only clip.mov", remuxer.inputs)
+	}
+	if len(mock.AnalyzedPaths) != 2 {
+		t.Fatalf("analyzed %d paths, want 2", len(mock.AnalyzedPaths))
+	}
+	for _, analyzed := range mock.AnalyzedPaths {
+		if analyzed == "clip.mov" || !strings.HasSuffix(analyzed, ".mp4") {
+			t.Errorf("analyzed path = %q, want clip.mp4 or a temporary mp4", analyzed)
+		}
+	}
+	if len(results) != 2 || results[0].VideoPath != "clip.mov" {
+		t.Errorf("results = %+v, want the first record to name clip.mov", results)
+	}
+}
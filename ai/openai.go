@@ -0,0 +1,178 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// openAIModelName is the multimodal model used when the OpenAI provider is
+// selected.
+const openAIModelName = "gpt-4o"
+
+// openAIBaseURL is the default API endpoint; tests override it.
+const openAIBaseURL = "https://api.openai.com/v1"
+
+// OpenAIClient analyzes videos with a multimodal OpenAI model. It satisfies
+// the same AIClient interface as GeminiAIClient so AnalysisManager stays
+// provider-agnostic.
+type OpenAIClient struct {
+	apiKey     string
+	model      string
+	baseURL    string
+	httpClient *http.Client
+	prompts    *PromptManager
+}
+
+// NewOpenAIClient creates an OpenAI-backed client authenticated with apiKey.
+func NewOpenAIClient(apiKey string) (*OpenAIClient, error) {
+	if apiKey == "" {
+		return nil, errors.New("openai API key is required")
+	}
+	prompts, err := NewPromptManager()
+	if err != nil {
+		return nil, err
+	}
+	return &OpenAIClient{
+		apiKey:     apiKey,
+		model:      openAIModelName,
+		baseURL:    openAIBaseURL,
+		httpClient: http.DefaultClient,
+		prompts:    prompts,
+	}, nil
+}
+
+// chatRequest and friends mirror the subset of the chat completions API we
+// use; hand-rolled to avoid pulling in an SDK dependency.
+type chatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []chatMessage   `json:"messages"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+type chatMessage struct {
+	Role    string        `json:"role"`
+	Content []contentPart `json:"content"`
+}
+
+type contentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		PromptTokens     int32 `json:"prompt_tokens"`
+		CompletionTokens int32 `json:"completion_tokens"`
+		TotalTokens      int32 `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// AnalyzeVideo sends the clip's media to the model as an inline data URL and
+// maps the JSON reply into a VideoAnalysisResponse.
+func (c *OpenAIClient) AnalyzeVideo(ctx context.Context, videoPath string) (*VideoAnalysisResponse, error) {
+	data, err := os.ReadFile(videoPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading video: %w", err)
+	}
+
+	prompt, err := c.prompts.VideoAnalysisPrompt(VideoAnalysisPromptData{VideoPath: videoPath})
+	if err != nil {
+		return nil, err
+	}
+
+	dataURL := fmt.Sprintf("data:%s;base64,%s",
+		mimeTypeForFile(videoPath), base64.StdEncoding.EncodeToString(data))
+	reqBody := chatRequest{
+		Model: c.model,
+		Messages: []chatMessage{{
+			Role: "user",
+			Content: []contentPart{
+				{Type: "text", Text: prompt},
+				{Type: "image_url", ImageURL: &imageURL{URL: dataURL}},
+			},
+		}},
+		ResponseFormat: &responseFormat{Type: "json_object"},
+	}
+	payload, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("analyzing %s: %w", videoPath, err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("analyzing %s: openai returned %s", videoPath, httpResp.Status)
+	}
+
+	var chat chatResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&chat); err != nil {
+		return nil, fmt.Errorf("decoding openai response: %w", err)
+	}
+	if len(chat.Choices) == 0 {
+		return nil, fmt.Errorf("analyzing %s: openai returned no choices", videoPath)
+	}
+
+	result, err := parseAnalysisResponse(chat.Choices[0].Message.Content)
+	if err != nil {
+		return nil, fmt.Errorf("parsing analysis of %s: %w", videoPath, err)
+	}
+	result.VideoPath = videoPath
+	if chat.Usage.TotalTokens > 0 {
+		result.TokenUsage = &TokenUsage{
+			PromptTokens:    chat.Usage.PromptTokens,
+			CandidateTokens: chat.Usage.CompletionTokens,
+			TotalTokens:     chat.Usage.TotalTokens,
+		}
+	}
+	return result, nil
+}
+
+// Close implements AIClient. The OpenAI client holds no persistent
+// connections beyond the shared HTTP transport.
+func (c *OpenAIClient) Close() error {
+	return nil
+}
+
+// NewAIClientFromEnv selects an analysis provider from the AI_PROVIDER
+// environment variable: "gemini" (the default) or "openai". The matching
+// GEMINI_API_KEY or OPENAI_API_KEY variable supplies credentials.
+func NewAIClientFromEnv(ctx context.Context) (AIClient, error) {
+	switch provider := os.Getenv("AI_PROVIDER"); provider {
+	case "", "gemini":
+		return NewGeminiAIClient(ctx, os.Getenv("GEMINI_API_KEY"))
+	case "openai":
+		return NewOpenAIClient(os.Getenv("OPENAI_API_KEY"))
+	default:
+		return nil, fmt.Errorf("unknown AI_PROVIDER %q (want gemini or openai)", provider)
+	}
+}
@@ -0,0 +1,72 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var _ AIClient = (*OpenAIClient)(nil)
+
+func TestOpenAIClientMapsCannedResponse(t *testing.T) {
+	analysis := map[string]any{
+		"description": "a song plays over driving footage",
+		"transcript":  "la la la",
+		"has_music":   true,
+		"song":        map[string]any{"title": "Test Song", "artist": "Test Artist"},
+		"urls":        map[string]any{"youtube": "https://youtube.com/watch?v=abc"},
+	}
+	content, _ := json.Marshal(analysis)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{
+				{"message": map[string]any{"content": string(content)}},
+			},
+			"usage": map[string]any{
+				"prompt_tokens":     10,
+				"completion_tokens": 5,
+				"total_tokens":      15,
+			},
+		})
+	}))
+	defer server.Close()
+
+	client, err := NewOpenAIClient("test-key")
+	if err != nil {
+		t.Fatalf("NewOpenAIClient: %v", err)
+	}
+	client.baseURL = server.URL
+
+	videoPath := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(videoPath, []byte("fake video bytes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.AnalyzeVideo(context.Background(), videoPath)
+	if err != nil {
+		t.Fatalf("AnalyzeVideo: %v", err)
+	}
+	if resp.VideoPath != videoPath {
+		t.Errorf("VideoPath = %q, want %q", resp.VideoPath, videoPath)
+	}
+	if !resp.HasMusic || resp.Song.Title != "Test Song" || resp.Song.Artist != "Test Artist" {
+		t.Errorf("song mapping wrong: %+v", resp)
+	}
+	if resp.URLs.YouTube != "https://youtube.com/watch?v=abc" {
+		t.Errorf("URLs.YouTube = %q", resp.URLs.YouTube)
+	}
+	if resp.TokenUsage == nil || resp.TokenUsage.TotalTokens != 15 {
+		t.Errorf("TokenUsage = %+v, want total 15", resp.TokenUsage)
+	}
+}
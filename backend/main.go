@@ -1,16 +1,168 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"example.com/ai"
 )
 
 func handler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Hello, Bazel + Go!")
 }
 
+// writeJSON writes v to w as a JSON body with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v map[string]string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// healthzHandler reports liveness: the process is up and serving requests.
+func healthzHandler(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readyzHandler reports readiness. With requireAPIKey set it also checks
+// that GEMINI_API_KEY is present, so orchestrators only route traffic here
+// once analysis can actually run.
+func readyzHandler(requireAPIKey bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if requireAPIKey && os.Getenv("GEMINI_API_KEY") == "" {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"status": "unavailable",
+				"reason": "GEMINI_API_KEY is not set",
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+	}
+}
+
+// analyzeRequest is the POST /analyze body.
+type analyzeRequest struct {
+	Path string `json:"path"`
+}
+
+// analyzeHandler runs AI analysis for one uploaded chunk and returns the
+// resulting record as JSON. The client is injected so tests can use
+// ai.MockAIClient.
+func analyzeHandler(client ai.AIClient) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "use POST"})
+			return
+		}
+		var req analyzeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body: " + err.Error()})
+			return
+		}
+		if !strings.HasPrefix(req.Path, "gs://") {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "path must be a gs:// URI"})
+			return
+		}
+		manager := ai.NewAnalysisManager(client)
+		results, err := manager.AnalyzeVideos(r.Context(), []string{req.Path})
+		if err != nil || len(results) == 0 {
+			writeJSON(w, http.StatusBadGateway, map[string]string{"error": fmt.Sprintf("analyzing %s: %v", req.Path, err)})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(results[0])
+	}
+}
+
+// contextKey keys values the middleware stores in request contexts.
+type contextKey string
+
+// requestIDKey is the context key holding the request ID.
+const requestIDKey contextKey = "request-id"
+
+// requestIDFromContext returns the request ID the logging middleware stored,
+// or "" when the request did not pass through it.
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// newRequestID returns a random 16-hex-character request ID.
+func newRequestID() string {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// statusRecorder captures the status code a handler writes.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// loggingMiddleware logs every request with method, path, status, and
+// latency. Each request gets an ID (taken from an incoming X-Request-ID
+// header, otherwise generated) that is stored in the context for downstream
+// handlers and echoed in the X-Request-ID response header.
+func loggingMiddleware(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if id == "" {
+			id = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", id)
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+		logger.Info("request",
+			"request_id", id,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"latency", time.Since(start),
+		)
+	})
+}
+
+// newMux builds the server's routes. A nil client leaves /analyze
+// unregistered.
+func newMux(requireAPIKey bool, client ai.AIClient) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handler)
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.HandleFunc("/readyz", readyzHandler(requireAPIKey))
+	if client != nil {
+		mux.HandleFunc("/analyze", analyzeHandler(client))
+	}
+	return mux
+}
+
 func main() {
-	http.HandleFunc("/", handler)
+	var client ai.AIClient
+	if apiKey := os.Getenv("GEMINI_API_KEY"); apiKey != "" {
+		c, err := ai.NewGeminiAIClient(context.Background(), apiKey)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "creating AI client: %v\n", err)
+			os.Exit(1)
+		}
+		defer c.Close()
+		client = c
+	}
+	mux := newMux(os.Getenv("READYZ_REQUIRE_API_KEY") != "", client)
 	fmt.Println("Listening on :8080...")
-	http.ListenAndServe(":8080", nil)
+	http.ListenAndServe(":8080", loggingMiddleware(slog.Default(), mux))
 }
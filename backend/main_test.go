@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"example.com/ai"
+)
+
+// decodeBody decodes the recorder's JSON body into a map.
+func decodeBody(t *testing.T, rec *httptest.ResponseRecorder) map[string]string {
+	t.Helper()
+	var body map[string]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body is not valid JSON: %v", err)
+	}
+	return body
+}
+
+func TestHealthzHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	healthzHandler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if body := decodeBody(t, rec); body["status"] != "ok" {
+		t.Errorf(`body["status"] = %q, want "ok"`, body["status"])
+	}
+}
+
+func TestReadyzHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	readyzHandler(false)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if body := decodeBody(t, rec); body["status"] != "ready" {
+		t.Errorf(`body["status"] = %q, want "ready"`, body["status"])
+	}
+}
+
+func TestReadyzHandlerRequiresAPIKey(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	rec := httptest.NewRecorder()
+	readyzHandler(true)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if body := decodeBody(t, rec); body["reason"] == "" {
+		t.Error("expected a reason explaining the failed readiness check")
+	}
+
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	rec = httptest.NewRecorder()
+	readyzHandler(true)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status with key = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestAnalyzeHandler(t *testing.T) {
+	mock := ai.NewMockAIClient()
+	mock.Response = &ai.VideoAnalysisResponse{
+		Description: "a road trip",
+		HasMusic:    true,
+		Song:        ai.Song{Title: "A Song", Artist: "An Artist"},
+	}
+	handle := analyzeHandler(mock)
+
+	body := strings.NewReader(`{"path": "gs://my-bucket/chunks/chunk_001.mp4"}`)
+	rec := httptest.NewRecorder()
+	handle(rec, httptest.NewRequest(http.MethodPost, "/analyze", body))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", rec.Code, http.StatusOK, rec.Body)
+	}
+	var resp ai.VideoAnalysisResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("response is not valid JSON: %v", err)
+	}
+	if resp.VideoPath != "gs://my-bucket/chunks/chunk_001.mp4" {
+		t.Errorf("video_path = %q", resp.VideoPath)
+	}
+	if !resp.HasMusic || resp.Song.Title != "A Song" {
+		t.Errorf("response = %+v, want the mock's analysis", resp)
+	}
+}
+
+func TestAnalyzeHandlerRejectsBadRequests(t *testing.T) {
+	handle := analyzeHandler(ai.NewMockAIClient())
+
+	rec := httptest.NewRecorder()
+	handle(rec, httptest.NewRequest(http.MethodGet, "/analyze", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+
+	rec = httptest.NewRecorder()
+	handle(rec, httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader(`{"path": "chunk.mp4"}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("local path status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+
+	rec = httptest.NewRecorder()
+	handle(rec, httptest.NewRequest(http.MethodPost, "/analyze", strings.NewReader("not json")))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("bad body status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestLoggingMiddleware(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+	var ctxID string
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctxID = requestIDFromContext(r.Context())
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	rec := httptest.NewRecorder()
+	loggingMiddleware(logger, inner).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	headerID := rec.Header().Get("X-Request-ID")
+	if headerID == "" {
+		t.Error("no X-Request-ID response header")
+	}
+	if ctxID != headerID {
+		t.Errorf("context request ID = %q, header = %q", ctxID, headerID)
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("log line is not valid JSON: %v (%s)", err, logs.String())
+	}
+	if entry["method"] != "GET" || entry["path"] != "/healthz" {
+		t.Errorf("log entry = %v, want method GET and path /healthz", entry)
+	}
+	if entry["status"] != float64(http.StatusTeapot) {
+		t.Errorf("log status = %v, want %d", entry["status"], http.StatusTeapot)
+	}
+	if entry["request_id"] != headerID {
+		t.Errorf("log request_id = %v, want %q", entry["request_id"], headerID)
+	}
+	if _, ok := entry["latency"]; !ok {
+		t.Error("log entry has no latency field")
+	}
+}
+
+func TestLoggingMiddlewareHonorsIncomingRequestID(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(&bytes.Buffer{}, nil))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "caller-supplied")
+	rec := httptest.NewRecorder()
+	loggingMiddleware(logger, newMux(false, nil)).ServeHTTP(rec, req)
+	if got := rec.Header().Get("X-Request-ID"); got != "caller-supplied" {
+		t.Errorf("X-Request-ID = %q, want the caller's ID echoed back", got)
+	}
+}
+
+func TestMuxServesRoot(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newMux(false, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec.Body.String() != "Hello, Bazel + Go!" {
+		t.Errorf("body = %q", rec.Body.String())
+	}
+}
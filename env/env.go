@@ -0,0 +1,170 @@
+// Package env centralizes pipeline configuration from the process
+// environment and optional .env files.
+package env
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the settings shared across pipeline commands.
+type Config struct {
+	GeminiAPIKey string `json:"gemini_api_key" yaml:"gemini_api_key"`
+	GCPProjectID string `json:"gcp_project_id" yaml:"gcp_project_id"`
+	GCPZone      string `json:"gcp_zone" yaml:"gcp_zone"`
+	GCSBucket    string `json:"gcs_bucket" yaml:"gcs_bucket"`
+
+	// Video splitting defaults.
+	ChunkDuration int    `json:"chunk_duration" yaml:"chunk_duration"`
+	OutputDir     string `json:"output_dir" yaml:"output_dir"`
+}
+
+// DefaultVideoConfig returns the built-in video splitting defaults.
+func DefaultVideoConfig() (chunkDuration int, outputDir string) {
+	return 30, "out"
+}
+
+// Load builds a Config from the process environment, first merging in a
+// .env file from the working directory when one exists. GEMINI_API_KEY is
+// required.
+func Load() (*Config, error) {
+	if _, err := os.Stat(".env"); err == nil {
+		if err := LoadFromFile(".env"); err != nil {
+			return nil, err
+		}
+	}
+	chunkDuration, outputDir := DefaultVideoConfig()
+	cfg := &Config{
+		GeminiAPIKey:  os.Getenv("GEMINI_API_KEY"),
+		GCPProjectID:  os.Getenv("GCP_PROJECT_ID"),
+		GCPZone:       os.Getenv("GCP_ZONE"),
+		GCSBucket:     os.Getenv("GCS_BUCKET"),
+		ChunkDuration: chunkDuration,
+		OutputDir:     outputDir,
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadConfig builds a Config by layering, lowest precedence first: built-in
+// defaults, the YAML or JSON file at path, then process env vars. Callers
+// apply explicit CLI flags on top of the result. An empty path skips the
+// file layer.
+func LoadConfig(path string) (*Config, error) {
+	chunkDuration, outputDir := DefaultVideoConfig()
+	cfg := &Config{ChunkDuration: chunkDuration, OutputDir: outputDir}
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config %s: %w", path, err)
+		}
+		if err := unmarshalConfig(path, data, cfg); err != nil {
+			return nil, err
+		}
+	}
+	applyEnvOverrides(cfg)
+	return cfg, nil
+}
+
+// unmarshalConfig decodes the config file by extension; anything that is
+// not .json is treated as YAML.
+func unmarshalConfig(path string, data []byte, cfg *Config) error {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+		return nil
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnvOverrides lets process env vars win over config-file values.
+func applyEnvOverrides(cfg *Config) {
+	if v := os.Getenv("GEMINI_API_KEY"); v != "" {
+		cfg.GeminiAPIKey = v
+	}
+	if v := os.Getenv("GCP_PROJECT_ID"); v != "" {
+		cfg.GCPProjectID = v
+	}
+	if v := os.Getenv("GCP_ZONE"); v != "" {
+		cfg.GCPZone = v
+	}
+	if v := os.Getenv("GCS_BUCKET"); v != "" {
+		cfg.GCSBucket = v
+	}
+}
+
+var (
+	// zonePattern matches GCP zone (us-central1-a) and region (us-central1)
+	// names.
+	zonePattern = regexp.MustCompile(`^[a-z]+-[a-z]+\d(-[a-z])?$`)
+	// bucketPattern enforces GCS bucket naming: 3-63 lowercase letters,
+	// digits, dashes, underscores, and dots, starting and ending with a
+	// letter or digit.
+	bucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{1,61}[a-z0-9]$`)
+)
+
+// Validate checks the configuration and reports every problem at once.
+func (c *Config) Validate() error {
+	var errs []error
+	if c.GeminiAPIKey == "" {
+		errs = append(errs, errors.New("GEMINI_API_KEY is not set"))
+	}
+	if c.GCPProjectID == "" {
+		errs = append(errs, errors.New("GCP project ID is not set"))
+	}
+	if c.GCPZone != "" && !zonePattern.MatchString(c.GCPZone) {
+		errs = append(errs, fmt.Errorf("zone %q does not look like a GCP zone (e.g. us-central1-a)", c.GCPZone))
+	}
+	if c.GCSBucket != "" && !bucketPattern.MatchString(c.GCSBucket) {
+		errs = append(errs, fmt.Errorf("bucket %q violates GCS bucket naming rules", c.GCSBucket))
+	}
+	return errors.Join(errs...)
+}
+
+// LoadFromFile merges KEY=VALUE pairs from a .env-style file into the
+// process environment. Comments and blank lines are ignored, and variables
+// already present in the environment take precedence.
+func LoadFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", path, err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return fmt.Errorf("%s:%d: not a KEY=VALUE line: %q", path, lineNo, line)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		if _, exists := os.LookupEnv(key); exists {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,169 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# local dev settings
+PIPELINE_TEST_FROM_FILE=file-value
+
+PIPELINE_TEST_ALREADY_SET="file-loses"
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Unsetenv("PIPELINE_TEST_FROM_FILE")
+	defer os.Unsetenv("PIPELINE_TEST_FROM_FILE")
+	t.Setenv("PIPELINE_TEST_ALREADY_SET", "env-wins")
+
+	if err := LoadFromFile(path); err != nil {
+		t.Fatalf("LoadFromFile: %v", err)
+	}
+	if got := os.Getenv("PIPELINE_TEST_FROM_FILE"); got != "file-value" {
+		t.Errorf("PIPELINE_TEST_FROM_FILE = %q, want %q", got, "file-value")
+	}
+	if got := os.Getenv("PIPELINE_TEST_ALREADY_SET"); got != "env-wins" {
+		t.Errorf("PIPELINE_TEST_ALREADY_SET = %q, want the real env var to win", got)
+	}
+}
+
+func TestLoadFromFileRejectsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("not a key value line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFromFile(path); err == nil {
+		t.Error("expected an error for a malformed line")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{
+		GeminiAPIKey: "test-key",
+		GCPProjectID: "test-project",
+		GCPZone:      "us-central1-a",
+		GCSBucket:    "my-chunks.bucket-1",
+	}
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		wantErr string
+	}{
+		{"valid", func(c *Config) {}, ""},
+		{"region instead of zone", func(c *Config) { c.GCPZone = "us-central1" }, ""},
+		{"optional zone and bucket", func(c *Config) { c.GCPZone = ""; c.GCSBucket = "" }, ""},
+		{"missing api key", func(c *Config) { c.GeminiAPIKey = "" }, "GEMINI_API_KEY"},
+		{"missing project", func(c *Config) { c.GCPProjectID = "" }, "project ID"},
+		{"implausible zone", func(c *Config) { c.GCPZone = "uswest" }, "zone"},
+		{"uppercase bucket", func(c *Config) { c.GCSBucket = "My-Bucket" }, "bucket"},
+		{"bucket too short", func(c *Config) { c.GCSBucket = "ab" }, "bucket"},
+		{"bucket bad characters", func(c *Config) { c.GCSBucket = "my bucket!" }, "bucket"},
+	}
+	for _, tt := range tests {
+		cfg := valid
+		tt.mutate(&cfg)
+		err := cfg.Validate()
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: Validate() = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Validate() = %v, want error mentioning %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestConfigValidateAggregatesErrors(t *testing.T) {
+	cfg := Config{GCPZone: "nowhere", GCSBucket: "BAD"}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("Validate() = nil, want errors")
+	}
+	for _, want := range []string{"GEMINI_API_KEY", "project ID", "zone", "bucket"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("aggregated error %q should mention %q", err, want)
+		}
+	}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipeline.yaml")
+	content := `gcp_project_id: file-project
+gcs_bucket: file-bucket
+chunk_duration: 45
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Env var beats the file; the file beats the built-in defaults.
+	t.Setenv("GCS_BUCKET", "env-bucket")
+	t.Setenv("GCP_PROJECT_ID", "")
+	os.Unsetenv("GCP_PROJECT_ID")
+	t.Setenv("GEMINI_API_KEY", "")
+	os.Unsetenv("GEMINI_API_KEY")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.GCSBucket != "env-bucket" {
+		t.Errorf("GCSBucket = %q, want the env var to win", cfg.GCSBucket)
+	}
+	if cfg.GCPProjectID != "file-project" {
+		t.Errorf("GCPProjectID = %q, want the file value", cfg.GCPProjectID)
+	}
+	if cfg.ChunkDuration != 45 {
+		t.Errorf("ChunkDuration = %d, want the file value 45", cfg.ChunkDuration)
+	}
+	if cfg.OutputDir != "out" {
+		t.Errorf("OutputDir = %q, want the built-in default", cfg.OutputDir)
+	}
+}
+
+func TestLoadConfigJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipeline.json")
+	if err := os.WriteFile(path, []byte(`{"gcp_zone": "us-central1-a"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GCP_ZONE", "")
+	os.Unsetenv("GCP_ZONE")
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.GCPZone != "us-central1-a" {
+		t.Errorf("GCPZone = %q", cfg.GCPZone)
+	}
+}
+
+func TestLoadRequiresGeminiAPIKey(t *testing.T) {
+	t.Setenv("GEMINI_API_KEY", "")
+	os.Unsetenv("GEMINI_API_KEY")
+	if _, err := Load(); err == nil {
+		t.Error("expected an error when GEMINI_API_KEY is unset")
+	}
+
+	t.Setenv("GEMINI_API_KEY", "test-key")
+	t.Setenv("GCP_PROJECT_ID", "test-project")
+	t.Setenv("GCS_BUCKET", "my-bucket")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.GeminiAPIKey != "test-key" || cfg.GCSBucket != "my-bucket" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	if cfg.ChunkDuration != 30 || cfg.OutputDir != "out" {
+		t.Errorf("video defaults = (%d, %q), want (30, \"out\")", cfg.ChunkDuration, cfg.OutputDir)
+	}
+}
@@ -0,0 +1,775 @@
+// Package export converts analysis output (JSON lines from the AI pipeline)
+// into tabular playlist files.
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// csvColumns is the default column set, in output order.
+var csvColumns = []string{
+	"video_path",
+	"description",
+	"transcript",
+	"language",
+	"has_music",
+	"song_title",
+	"song_artist",
+	"web_search_song_title",
+	"web_search_song_artist",
+	"youtube_url",
+	"spotify_url",
+}
+
+// Exporter writes parsed analysis records in some output format. CSVExporter
+// and JSONExporter are the built-in implementations.
+type Exporter interface {
+	WriteHeader() error
+	WriteRecord(record map[string]interface{}) error
+	Flush() error
+}
+
+// CSVExporter writes records as CSV rows.
+type CSVExporter struct {
+	w        io.Writer
+	writer   *csv.Writer
+	columns  []string
+	quoteAll bool
+}
+
+// NewCSVExporter creates a CSV exporter writing the default columns to w.
+func NewCSVExporter(w io.Writer) *CSVExporter {
+	return NewCSVExporterWithColumns(w, nil)
+}
+
+// NewCSVExporterWithColumns creates a CSV exporter that writes only the
+// given record keys, in order, as both the header and each row. A nil or
+// empty selection means the default column set.
+func NewCSVExporterWithColumns(w io.Writer, columns []string) *CSVExporter {
+	if len(columns) == 0 {
+		columns = csvColumns
+	}
+	return &CSVExporter{w: w, writer: csv.NewWriter(w), columns: columns}
+}
+
+// SetDelimiter changes the field delimiter, e.g. '\t' for TSV output. It
+// must be called before any rows are written. Fields containing the
+// delimiter are quoted by the underlying csv.Writer as usual.
+func (e *CSVExporter) SetDelimiter(delimiter rune) {
+	e.writer.Comma = delimiter
+}
+
+// SetQuoteAll forces every field to be wrapped in double quotes, for
+// downstream parsers that expect fully quoted CSV. The default quotes only
+// fields that need it. It must be called before any rows are written.
+func (e *CSVExporter) SetQuoteAll(quote bool) {
+	e.quoteAll = quote
+}
+
+// WriteHeader writes the column header row.
+func (e *CSVExporter) WriteHeader() error {
+	return e.writeRow(e.columns)
+}
+
+// WriteRecord writes one record as a CSV row, leaving missing columns empty.
+func (e *CSVExporter) WriteRecord(record map[string]interface{}) error {
+	row := make([]string, len(e.columns))
+	for i, col := range e.columns {
+		row[i] = formatValue(record[col])
+	}
+	return e.writeRow(row)
+}
+
+// writeRow writes one row. With quote-all set it quotes every field itself,
+// doubling embedded quotes; otherwise the csv.Writer's minimal quoting
+// applies.
+func (e *CSVExporter) writeRow(fields []string) error {
+	if !e.quoteAll {
+		return e.writer.Write(fields)
+	}
+	quoted := make([]string, len(fields))
+	for i, f := range fields {
+		quoted[i] = `"` + strings.ReplaceAll(f, `"`, `""`) + `"`
+	}
+	_, err := fmt.Fprintln(e.w, strings.Join(quoted, string(e.writer.Comma)))
+	return err
+}
+
+// Flush flushes buffered rows to the underlying writer.
+func (e *CSVExporter) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// JSONExporter accumulates records and writes them as a pretty-printed JSON
+// array on Flush.
+type JSONExporter struct {
+	w       io.Writer
+	records []map[string]interface{}
+}
+
+// NewJSONExporter creates a JSON exporter writing to w.
+func NewJSONExporter(w io.Writer) *JSONExporter {
+	return &JSONExporter{w: w}
+}
+
+// WriteHeader is a no-op; JSON output has no header.
+func (e *JSONExporter) WriteHeader() error {
+	return nil
+}
+
+// WriteRecord buffers one record for the final array.
+func (e *JSONExporter) WriteRecord(record map[string]interface{}) error {
+	e.records = append(e.records, record)
+	return nil
+}
+
+// Flush writes the accumulated records as an indented JSON array.
+func (e *JSONExporter) Flush() error {
+	out, err := json.MarshalIndent(e.records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding records: %w", err)
+	}
+	out = append(out, '\n')
+	_, err = e.w.Write(out)
+	return err
+}
+
+// M3UExporter writes records as an extended M3U playlist, linking each entry
+// to its YouTube URL.
+type M3UExporter struct {
+	w io.Writer
+}
+
+// NewM3UExporter creates an M3U exporter writing to w.
+func NewM3UExporter(w io.Writer) *M3UExporter {
+	return &M3UExporter{w: w}
+}
+
+// WriteHeader writes the extended M3U signature.
+func (e *M3UExporter) WriteHeader() error {
+	_, err := fmt.Fprintln(e.w, "#EXTM3U")
+	return err
+}
+
+// WriteRecord writes one EXTINF entry labeled "Artist - Title". Records
+// without a YouTube URL are skipped.
+func (e *M3UExporter) WriteRecord(record map[string]interface{}) error {
+	url := strings.TrimSpace(formatValue(record["youtube_url"]))
+	if url == "" {
+		return nil
+	}
+	title := strings.TrimSpace(formatValue(record["web_search_song_title"]))
+	artist := strings.TrimSpace(formatValue(record["web_search_song_artist"]))
+	if title == "" {
+		title = strings.TrimSpace(formatValue(record["song_title"]))
+		artist = strings.TrimSpace(formatValue(record["song_artist"]))
+	}
+	label := title
+	if artist != "" {
+		label = artist + " - " + title
+	}
+	_, err := fmt.Fprintf(e.w, "#EXTINF:-1,%s\n%s\n", label, url)
+	return err
+}
+
+// Flush implements Exporter; M3U output is written as it comes.
+func (e *M3UExporter) Flush() error {
+	return nil
+}
+
+// SubtitleExporter writes chunk transcripts as an SRT subtitle file. Cue
+// timing comes from a record's explicit start field (seconds) when present,
+// otherwise from the record's position multiplied by the chunk duration.
+type SubtitleExporter struct {
+	w             io.Writer
+	chunkDuration time.Duration
+	cue           int // last SRT cue index written
+	position      int // records seen, for position-derived timing
+}
+
+// NewSubtitleExporter creates an SRT exporter writing to w for chunks of the
+// given duration.
+func NewSubtitleExporter(w io.Writer, chunkDuration time.Duration) *SubtitleExporter {
+	return &SubtitleExporter{w: w, chunkDuration: chunkDuration}
+}
+
+// WriteHeader is a no-op; SRT files have no header.
+func (e *SubtitleExporter) WriteHeader() error {
+	return nil
+}
+
+// WriteRecord writes one cue spanning the record's chunk. Records without a
+// transcript advance the clock but produce no cue.
+func (e *SubtitleExporter) WriteRecord(record map[string]interface{}) error {
+	start := time.Duration(e.position) * e.chunkDuration
+	if secs, ok := record["start"].(float64); ok {
+		start = time.Duration(secs * float64(time.Second))
+	}
+	e.position++
+
+	transcript := strings.TrimSpace(formatValue(record["transcript"]))
+	if transcript == "" {
+		return nil
+	}
+	e.cue++
+	_, err := fmt.Fprintf(e.w, "%d\n%s --> %s\n%s\n\n",
+		e.cue, srtTimestamp(start), srtTimestamp(start+e.chunkDuration), transcript)
+	return err
+}
+
+// srtTimestamp renders a duration as the HH:MM:SS,mmm form SRT requires.
+func srtTimestamp(d time.Duration) string {
+	millis := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d,%03d",
+		millis/3600000, millis/60000%60, millis/1000%60, millis%1000)
+}
+
+// Flush implements Exporter; cues are written as they come.
+func (e *SubtitleExporter) Flush() error {
+	return nil
+}
+
+// MarkdownExporter writes records as a GitHub-flavored Markdown table using
+// the default column set.
+type MarkdownExporter struct {
+	w       io.Writer
+	columns []string
+}
+
+// NewMarkdownExporter creates a Markdown exporter writing to w.
+func NewMarkdownExporter(w io.Writer) *MarkdownExporter {
+	return &MarkdownExporter{w: w, columns: csvColumns}
+}
+
+// WriteHeader writes the column header row and the |---| separator row.
+func (e *MarkdownExporter) WriteHeader() error {
+	if err := e.writeRow(e.columns); err != nil {
+		return err
+	}
+	separators := make([]string, len(e.columns))
+	for i := range separators {
+		separators[i] = "---"
+	}
+	return e.writeRow(separators)
+}
+
+// WriteRecord writes one record as a table row, leaving missing columns
+// empty.
+func (e *MarkdownExporter) WriteRecord(record map[string]interface{}) error {
+	cells := make([]string, len(e.columns))
+	for i, col := range e.columns {
+		cells[i] = escapeMarkdownCell(formatValue(record[col]))
+	}
+	return e.writeRow(cells)
+}
+
+// writeRow writes one pipe-delimited table row.
+func (e *MarkdownExporter) writeRow(cells []string) error {
+	_, err := fmt.Fprintf(e.w, "| %s |\n", strings.Join(cells, " | "))
+	return err
+}
+
+// escapeMarkdownCell makes a value safe inside a table cell: pipes are
+// escaped and newlines collapsed to spaces.
+func escapeMarkdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	s = strings.ReplaceAll(s, "\r\n", " ")
+	s = strings.ReplaceAll(s, "\n", " ")
+	return s
+}
+
+// Flush implements Exporter; Markdown output is written as it comes.
+func (e *MarkdownExporter) Flush() error {
+	return nil
+}
+
+// YouTubeURLExporter writes a newline-delimited list of unique YouTube URLs,
+// the format playlist importers accept. Records without a URL are skipped.
+type YouTubeURLExporter struct {
+	w    io.Writer
+	seen map[string]bool
+}
+
+// NewYouTubeURLExporter creates a YouTube URL list exporter writing to w.
+func NewYouTubeURLExporter(w io.Writer) *YouTubeURLExporter {
+	return &YouTubeURLExporter{w: w, seen: make(map[string]bool)}
+}
+
+// WriteHeader is a no-op; the URL list has no header.
+func (e *YouTubeURLExporter) WriteHeader() error {
+	return nil
+}
+
+// WriteRecord writes the record's YouTube URL on its own line, once per
+// distinct URL.
+func (e *YouTubeURLExporter) WriteRecord(record map[string]interface{}) error {
+	url := strings.TrimSpace(formatValue(record["youtube_url"]))
+	if url == "" || e.seen[url] {
+		return nil
+	}
+	e.seen[url] = true
+	_, err := fmt.Fprintln(e.w, url)
+	return err
+}
+
+// Flush implements Exporter; URLs are written as they come.
+func (e *YouTubeURLExporter) Flush() error {
+	return nil
+}
+
+// formatValue renders a parsed JSON value as a cell string.
+func formatValue(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	case float64:
+		if value == float64(int64(value)) {
+			return strconv.FormatInt(int64(value), 10)
+		}
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+// ExportManager parses analysis output and feeds it through an Exporter.
+type ExportManager struct {
+	exporter      Exporter
+	parser        *RelaxedJSONParser
+	filter        func(map[string]interface{}) bool
+	strict        bool
+	minConfidence float64
+	language      string
+
+	dedupeMode DedupeMode
+	dedupeKeys []string
+	dedupeSeen map[string]bool
+	dedupeLast string
+
+	seenPaths map[string]bool
+
+	parseStatsMu sync.Mutex
+	parseStats   map[ParseMethod]int
+}
+
+// NewExportManager creates a manager that writes through the given exporter,
+// so output format selection is pluggable.
+func NewExportManager(exporter Exporter) *ExportManager {
+	return &ExportManager{
+		exporter:   exporter,
+		parser:     NewRelaxedJSONParser(),
+		parseStats: make(map[ParseMethod]int),
+		seenPaths:  make(map[string]bool),
+	}
+}
+
+// countParseMethod tallies one parse outcome. Safe for concurrent use by the
+// parse workers.
+func (m *ExportManager) countParseMethod(method ParseMethod) {
+	m.parseStatsMu.Lock()
+	defer m.parseStatsMu.Unlock()
+	m.parseStats[method]++
+}
+
+// ParseMethodCounts reports how many lines each parse strategy handled so
+// far, a proxy for how often the model emits clean JSON.
+func (m *ExportManager) ParseMethodCounts() map[ParseMethod]int {
+	m.parseStatsMu.Lock()
+	defer m.parseStatsMu.Unlock()
+	counts := make(map[ParseMethod]int, len(m.parseStats))
+	for method, n := range m.parseStats {
+		counts[method] = n
+	}
+	return counts
+}
+
+// ExportFromFile reads line-delimited analysis output from path and exports
+// it.
+func (m *ExportManager) ExportFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	defer f.Close()
+	return m.ExportFromReader(f)
+}
+
+// ExportFromReader streams line-delimited analysis output from r. Input that
+// starts with a JSON array is buffered and handled as a whole instead.
+func (m *ExportManager) ExportFromReader(r io.Reader) error {
+	buffered := bufio.NewReader(r)
+	if first, err := peekFirstByte(buffered); err == nil && first == '[' {
+		data, err := io.ReadAll(buffered)
+		if err != nil {
+			return fmt.Errorf("reading input: %w", err)
+		}
+		return m.ExportFromString(string(data))
+	}
+	if err := m.exporter.WriteHeader(); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+	if err := m.streamRecords(buffered); err != nil {
+		return err
+	}
+	return m.exporter.Flush()
+}
+
+// peekFirstByte reports the first non-whitespace byte of r without consuming
+// it.
+func peekFirstByte(r *bufio.Reader) (byte, error) {
+	for skip := 0; ; skip++ {
+		peeked, err := r.Peek(skip + 1)
+		if err != nil {
+			return 0, err
+		}
+		if b := peeked[skip]; !unicode.IsSpace(rune(b)) {
+			return b, nil
+		}
+	}
+}
+
+// SetStrict controls what happens when a line cannot be parsed: by default
+// it is skipped with a warning, but in strict mode the export aborts with an
+// error naming the offending line, so automated runs catch malformed model
+// output instead of silently losing records.
+func (m *ExportManager) SetStrict(strict bool) {
+	m.strict = strict
+}
+
+// SetRepairTruncated forwards to RelaxedJSONParser.SetRepairTruncated,
+// enabling best-effort recovery of objects cut off mid-stream.
+func (m *ExportManager) SetRepairTruncated(repair bool) {
+	m.parser.SetRepairTruncated(repair)
+}
+
+// SetFilter installs a predicate applied to each parsed record before it is
+// written; records for which it returns false are dropped. A nil filter
+// (the default) keeps everything.
+func (m *ExportManager) SetFilter(filter func(map[string]interface{}) bool) {
+	m.filter = filter
+}
+
+// SetMinConfidence drops records whose confidence field is below threshold.
+// The default threshold of 0 keeps every record, including ones without a
+// confidence field at all.
+func (m *ExportManager) SetMinConfidence(threshold float64) {
+	m.minConfidence = threshold
+}
+
+// SetLanguage drops records whose language field does not match the given
+// BCP-47 tag. Matching is case-insensitive and a bare primary subtag
+// matches regional variants, so "en" keeps "en-US". Records without a
+// language are dropped when a tag is set; the default empty tag keeps
+// everything.
+func (m *ExportManager) SetLanguage(tag string) {
+	m.language = tag
+}
+
+// languageMismatch reports whether record should be dropped for not
+// matching the configured language tag.
+func (m *ExportManager) languageMismatch(record map[string]interface{}) bool {
+	if m.language == "" {
+		return false
+	}
+	want := strings.ToLower(m.language)
+	got, _ := record["language"].(string)
+	got = strings.ToLower(got)
+	if got == want {
+		return false
+	}
+	return !strings.HasPrefix(got, want+"-")
+}
+
+// belowMinConfidence reports whether record should be dropped for scoring
+// under the configured threshold. A record without a numeric confidence
+// field counts as confidence 0.
+func (m *ExportManager) belowMinConfidence(record map[string]interface{}) bool {
+	if m.minConfidence <= 0 {
+		return false
+	}
+	confidence, _ := record["confidence"].(float64)
+	return confidence < m.minConfidence
+}
+
+// MusicOnlyFilter keeps only records whose has_music field is true.
+func MusicOnlyFilter(record map[string]interface{}) bool {
+	hasMusic, ok := record["has_music"].(bool)
+	return ok && hasMusic
+}
+
+// DedupeMode selects how duplicate songs are suppressed during export.
+type DedupeMode int
+
+const (
+	// DedupeOff writes every record.
+	DedupeOff DedupeMode = iota
+	// DedupeConsecutive skips a record whose key matches the previously
+	// emitted row, collapsing runs of chunks playing the same song.
+	DedupeConsecutive
+	// DedupeAll skips a record whose key matches any previously emitted
+	// row.
+	DedupeAll
+)
+
+// defaultDedupeKeys identify a song for duplicate detection.
+var defaultDedupeKeys = []string{"song_title", "song_artist"}
+
+// SetDedupe enables duplicate suppression keyed on the given record fields,
+// defaulting to (song_title, song_artist) when no keys are passed. Records
+// whose key fields are all empty are never treated as duplicates.
+func (m *ExportManager) SetDedupe(mode DedupeMode, keys ...string) {
+	if len(keys) == 0 {
+		keys = defaultDedupeKeys
+	}
+	m.dedupeMode = mode
+	m.dedupeKeys = keys
+	m.dedupeSeen = make(map[string]bool)
+	m.dedupeLast = ""
+}
+
+// isDuplicate reports whether record should be skipped under the configured
+// dedupe mode, updating the tracking state as a side effect.
+func (m *ExportManager) isDuplicate(record map[string]interface{}) bool {
+	if m.dedupeMode == DedupeOff {
+		return false
+	}
+	empty := true
+	parts := make([]string, len(m.dedupeKeys))
+	for i, key := range m.dedupeKeys {
+		parts[i] = formatValue(record[key])
+		if parts[i] != "" {
+			empty = false
+		}
+	}
+	if empty {
+		m.dedupeLast = ""
+		return false
+	}
+	key := strings.Join(parts, "\x00")
+	switch m.dedupeMode {
+	case DedupeConsecutive:
+		if key == m.dedupeLast {
+			return true
+		}
+	case DedupeAll:
+		if m.dedupeSeen[key] {
+			return true
+		}
+		m.dedupeSeen[key] = true
+	}
+	m.dedupeLast = key
+	return false
+}
+
+// ExportFromFiles streams line-delimited analysis output from several files
+// (e.g. per-shard dumps from a concurrent run) into a single export with one
+// header.
+func (m *ExportManager) ExportFromFiles(paths []string) error {
+	if err := m.exporter.WriteHeader(); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", path, err)
+		}
+		err = m.streamRecords(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("exporting %s: %w", path, err)
+		}
+	}
+	return m.exporter.Flush()
+}
+
+// ExportFromString exports analysis output, either line-delimited JSON
+// objects or a single JSON array of records. Entries that cannot be parsed
+// are skipped with a warning rather than aborting the export.
+func (m *ExportManager) ExportFromString(data string) error {
+	if err := m.exporter.WriteHeader(); err != nil {
+		return fmt.Errorf("writing header: %w", err)
+	}
+	if trimmed := strings.TrimSpace(data); strings.HasPrefix(trimmed, "[") {
+		var records []map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &records); err == nil {
+			for _, record := range records {
+				if err := m.writeRecord(record); err != nil {
+					return err
+				}
+			}
+			return m.exporter.Flush()
+		}
+		slog.Warn("input looks like a JSON array but did not parse as one; falling back to line mode")
+	}
+	if err := m.streamRecords(strings.NewReader(data)); err != nil {
+		return err
+	}
+	return m.exporter.Flush()
+}
+
+// exportParseWorkers bounds how many input lines are parsed concurrently.
+const exportParseWorkers = 4
+
+// maxExportLineBytes caps a single input line; analysis records are far
+// smaller, but transcripts can get long.
+const maxExportLineBytes = 16 << 20
+
+// indexedLine carries one input line through the parse pool.
+type indexedLine struct {
+	index  int // dense sequence number used for reordering
+	lineNo int // 1-based input line number for messages
+	text   string
+}
+
+// indexedRecord is a parse result waiting for its in-order write.
+type indexedRecord struct {
+	index    int
+	lineNo   int
+	record   map[string]interface{} // nil when the line was skipped
+	parseErr error                  // why the line was skipped, for strict mode
+	text     string                 // original line, for strict-mode errors
+}
+
+// streamRecords reads line-delimited analysis output from r, parsing lines
+// across a bounded worker pool and writing the records in input order via a
+// reordering buffer. Empty and malformed lines are skipped as before.
+func (m *ExportManager) streamRecords(r io.Reader) error {
+	jobs := make(chan indexedLine, exportParseWorkers)
+	results := make(chan indexedRecord, exportParseWorkers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < exportParseWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				record, method, err := m.parser.ParseJSONWithMethod(job.text)
+				m.countParseMethod(method)
+				if err != nil {
+					if !m.strict {
+						slog.Warn("skipping malformed line", "line", job.lineNo, "error", err)
+					}
+					results <- indexedRecord{index: job.index, lineNo: job.lineNo, parseErr: err, text: job.text}
+					continue
+				}
+				slog.Debug("parsed line", "line", job.lineNo, "method", method)
+				results <- indexedRecord{index: job.index, lineNo: job.lineNo, record: record}
+			}
+		}()
+	}
+
+	scanErr := make(chan error, 1)
+	go func() {
+		defer close(jobs)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxExportLineBytes)
+		index, lineNo := 0, 0
+		for scanner.Scan() {
+			lineNo++
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			jobs <- indexedLine{index: index, lineNo: lineNo, text: scanner.Text()}
+			index++
+		}
+		scanErr <- scanner.Err()
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Hold completed parses until their predecessors have been written so
+	// output order matches input order.
+	var writeErr error
+	pending := make(map[int]indexedRecord)
+	next := 0
+	for res := range results {
+		pending[res.index] = res
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if ready.parseErr != nil {
+				if m.strict && writeErr == nil {
+					writeErr = fmt.Errorf("line %d: parsing %q: %w", ready.lineNo, ready.text, ready.parseErr)
+				}
+				continue
+			}
+			if writeErr != nil {
+				continue
+			}
+			if err := m.writeRecord(ready.record); err != nil {
+				writeErr = fmt.Errorf("line %d: %w", ready.lineNo, err)
+			}
+		}
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	return <-scanErr
+}
+
+// checkDuplicatePath flags a video_path that already appeared in an earlier
+// record, which usually means the pipeline analyzed a chunk twice. The
+// duplicate is logged as a warning, or aborts the export in strict mode; the
+// record itself is still written so no data is silently lost.
+func (m *ExportManager) checkDuplicatePath(record map[string]interface{}) error {
+	path, _ := record["video_path"].(string)
+	if path == "" {
+		return nil
+	}
+	if !m.seenPaths[path] {
+		m.seenPaths[path] = true
+		return nil
+	}
+	if m.strict {
+		return fmt.Errorf("duplicate video_path %q in analysis output", path)
+	}
+	slog.Warn("duplicate video_path in analysis output", "path", path)
+	return nil
+}
+
+// writeRecord applies the configured filter and dedupe before handing a
+// record to the exporter.
+func (m *ExportManager) writeRecord(record map[string]interface{}) error {
+	if err := m.checkDuplicatePath(record); err != nil {
+		return err
+	}
+	if m.filter != nil && !m.filter(record) {
+		return nil
+	}
+	if m.belowMinConfidence(record) {
+		return nil
+	}
+	if m.languageMismatch(record) {
+		return nil
+	}
+	if m.isDuplicate(record) {
+		return nil
+	}
+	if err := m.exporter.WriteRecord(record); err != nil {
+		return fmt.Errorf("writing record: %w", err)
+	}
+	return nil
+}
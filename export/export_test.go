@@ -0,0 +1,608 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const sampleLines = `{"video_path": "chunk_001.mp4", "description": "driving", "has_music": true, "song_title": "Test Song", "song_artist": "Test Artist", "youtube_url": "https://youtube.com/watch?v=abc"}
+{"video_path": "chunk_002.mp4", "description": "talking", "has_music": false}
+`
+
+func TestExportFromStringWritesCSV(t *testing.T) {
+	var buf bytes.Buffer
+	manager := NewExportManager(NewCSVExporter(&buf))
+	if err := manager.ExportFromString(sampleLines); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV output: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2 records", len(rows))
+	}
+	if rows[0][0] != "video_path" || len(rows[0]) != 11 {
+		t.Errorf("header = %v, want eleven columns starting with video_path", rows[0])
+	}
+	if rows[1][0] != "chunk_001.mp4" || rows[1][4] != "true" || rows[1][5] != "Test Song" {
+		t.Errorf("first record = %v", rows[1])
+	}
+	if rows[2][0] != "chunk_002.mp4" || rows[2][4] != "false" {
+		t.Errorf("second record = %v", rows[2])
+	}
+}
+
+func TestExportFromStringSkipsMalformedLines(t *testing.T) {
+	var buf bytes.Buffer
+	manager := NewExportManager(NewCSVExporter(&buf))
+	input := `{"video_path": "good.mp4"}
+<<<not json at all>>>
+{"video_path": "also-good.mp4"}
+`
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Errorf("got %d rows, want header + 2 good records", len(rows))
+	}
+}
+
+func TestExportFromStringStrictModeFailsOnMalformedLines(t *testing.T) {
+	var buf bytes.Buffer
+	manager := NewExportManager(NewCSVExporter(&buf))
+	manager.SetStrict(true)
+	input := `{"video_path": "good.mp4"}
+<<<not json at all>>>
+{"video_path": "also-good.mp4"}
+`
+	err := manager.ExportFromString(input)
+	if err == nil {
+		t.Fatal("expected strict mode to fail on the malformed line")
+	}
+	if !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("error = %v, want it to name line 2", err)
+	}
+	if !strings.Contains(err.Error(), "not json at all") {
+		t.Errorf("error = %v, want it to include the offending content", err)
+	}
+}
+
+func TestExportFromFilesMergesWithOneHeader(t *testing.T) {
+	dir := t.TempDir()
+	fileA := filepath.Join(dir, "shard-a.ndjson")
+	fileB := filepath.Join(dir, "shard-b.ndjson")
+	if err := os.WriteFile(fileA, []byte(`{"video_path": "a1.mp4"}`+"\n"+`{"video_path": "a2.mp4"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fileB, []byte(`{"video_path": "b1.mp4"}`+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	manager := NewExportManager(NewCSVExporter(&buf))
+	if err := manager.ExportFromFiles([]string{fileA, fileB}); err != nil {
+		t.Fatalf("ExportFromFiles: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 {
+		t.Fatalf("got %d rows, want one header + 3 records", len(rows))
+	}
+	if rows[0][0] != "video_path" || rows[1][0] != "a1.mp4" || rows[3][0] != "b1.mp4" {
+		t.Errorf("merged rows = %v", rows)
+	}
+}
+
+func TestCSVExporterColumnSubset(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := NewCSVExporterWithColumns(&buf, []string{"song_title", "song_artist", "youtube_url"})
+	manager := NewExportManager(exporter)
+	if err := manager.ExportFromString(sampleLines); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"song_title", "song_artist", "youtube_url"}
+	if len(rows[0]) != 3 || rows[0][0] != want[0] || rows[0][1] != want[1] || rows[0][2] != want[2] {
+		t.Errorf("header = %v, want %v", rows[0], want)
+	}
+	if rows[1][0] != "Test Song" || rows[1][2] != "https://youtube.com/watch?v=abc" {
+		t.Errorf("first record = %v", rows[1])
+	}
+}
+
+func TestCSVExporterTabDelimiter(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := NewCSVExporter(&buf)
+	exporter.SetDelimiter('\t')
+
+	manager := NewExportManager(exporter)
+	input := `{"video_path": "chunk_001.mp4", "description": "field\twith embedded tab"}`
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	reader := csv.NewReader(bytes.NewReader(buf.Bytes()))
+	reader.Comma = '\t'
+	rows, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("reading TSV output: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want header + 1 record", len(rows))
+	}
+	if rows[1][1] != "field\twith embedded tab" {
+		t.Errorf("embedded tab not preserved: %q", rows[1][1])
+	}
+	if !strings.Contains(buf.String(), "\"field\twith embedded tab\"") {
+		t.Errorf("field containing the delimiter was not quoted:\n%s", buf.String())
+	}
+}
+
+func TestSetFilterExcludesRecords(t *testing.T) {
+	var buf bytes.Buffer
+	manager := NewExportManager(NewCSVExporter(&buf))
+	manager.SetFilter(MusicOnlyFilter)
+
+	input := `{"video_path": "music.mp4", "has_music": true}
+{"video_path": "talking.mp4", "has_music": false}
+{"video_path": "no-field.mp4"}
+{"video_path": "more-music.mp4", "has_music": true}
+`
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2 music records", len(rows))
+	}
+	if rows[1][0] != "music.mp4" || rows[2][0] != "more-music.mp4" {
+		t.Errorf("filtered rows = %v", rows)
+	}
+}
+
+func TestSetMinConfidenceExcludesLowScores(t *testing.T) {
+	var buf bytes.Buffer
+	manager := NewExportManager(NewCSVExporter(&buf))
+	manager.SetMinConfidence(0.6)
+
+	input := `{"video_path": "sure.mp4", "has_music": true, "confidence": 0.9}
+{"video_path": "guess.mp4", "has_music": true, "confidence": 0.3}
+{"video_path": "no-score.mp4", "has_music": true}
+{"video_path": "borderline.mp4", "has_music": true, "confidence": 0.6}
+`
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2 confident records", len(rows))
+	}
+	if rows[1][0] != "sure.mp4" || rows[2][0] != "borderline.mp4" {
+		t.Errorf("filtered rows = %v", rows)
+	}
+
+	buf.Reset()
+	manager = NewExportManager(NewCSVExporter(&buf))
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+	if rows, _ := csv.NewReader(&buf).ReadAll(); len(rows) != 5 {
+		t.Errorf("default threshold kept %d rows, want all 4 records", len(rows)-1)
+	}
+}
+
+func TestExportFromStringAcceptsJSONArray(t *testing.T) {
+	input := `[
+  {"video_path": "chunk_001.mp4", "has_music": true},
+  {"video_path": "chunk_002.mp4", "has_music": false}
+]`
+	var buf bytes.Buffer
+	manager := NewExportManager(NewCSVExporter(&buf))
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2 records", len(rows))
+	}
+	if rows[1][0] != "chunk_001.mp4" || rows[2][0] != "chunk_002.mp4" {
+		t.Errorf("rows = %v", rows)
+	}
+}
+
+func TestSetDedupeSkipsRepeatedSongs(t *testing.T) {
+	input := `{"video_path": "c1.mp4", "song_title": "Same Song", "song_artist": "Artist"}
+{"video_path": "c2.mp4", "song_title": "Same Song", "song_artist": "Artist"}
+{"video_path": "c3.mp4", "song_title": "Other Song", "song_artist": "Artist"}
+`
+	var buf bytes.Buffer
+	manager := NewExportManager(NewCSVExporter(&buf))
+	manager.SetDedupe(DedupeConsecutive)
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2 distinct songs", len(rows))
+	}
+	if rows[1][0] != "c1.mp4" || rows[2][0] != "c3.mp4" {
+		t.Errorf("deduped rows = %v", rows)
+	}
+}
+
+func TestSetDedupeAllSkipsNonConsecutiveRepeats(t *testing.T) {
+	input := `{"video_path": "c1.mp4", "song_title": "Same Song", "song_artist": "Artist"}
+{"video_path": "c2.mp4", "song_title": "Other Song", "song_artist": "Artist"}
+{"video_path": "c3.mp4", "song_title": "Same Song", "song_artist": "Artist"}
+{"video_path": "c4.mp4"}
+{"video_path": "c5.mp4"}
+`
+	var buf bytes.Buffer
+	manager := NewExportManager(NewCSVExporter(&buf))
+	manager.SetDedupe(DedupeAll)
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Two distinct songs plus both song-less records.
+	if len(rows) != 5 {
+		t.Fatalf("got %d rows, want header + 4", len(rows))
+	}
+}
+
+// countingExporter is a fake Exporter that tallies calls.
+type countingExporter struct {
+	headers int
+	records int
+	flushes int
+}
+
+func (e *countingExporter) WriteHeader() error                         { e.headers++; return nil }
+func (e *countingExporter) WriteRecord(_ map[string]interface{}) error { e.records++; return nil }
+func (e *countingExporter) Flush() error                               { e.flushes++; return nil }
+
+func TestExportManagerDrivesAnyExporter(t *testing.T) {
+	fake := &countingExporter{}
+	manager := NewExportManager(fake)
+	if err := manager.ExportFromString(sampleLines); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+	if fake.headers != 1 || fake.records != 2 || fake.flushes != 1 {
+		t.Errorf("got headers=%d records=%d flushes=%d, want 1/2/1", fake.headers, fake.records, fake.flushes)
+	}
+}
+
+func TestM3UExporterWritesPlaylist(t *testing.T) {
+	lines := `{"video_path": "chunk_001.mp4", "has_music": true, "song": {"title": "A Song", "artist": "An Artist"}, "urls": {"youtube": "https://youtube.com/watch?v=abc123"}}
+{"video_path": "chunk_002.mp4", "has_music": true, "song": {"title": "No URL Song"}}
+`
+	var buf bytes.Buffer
+	manager := NewExportManager(NewM3UExporter(&buf))
+	if err := manager.ExportFromString(lines); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	got := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	want := []string{
+		"#EXTM3U",
+		"#EXTINF:-1,An Artist - A Song",
+		"https://youtube.com/watch?v=abc123",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(got), len(want), buf.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestExportFromReaderPreservesOrderOnLargeInput(t *testing.T) {
+	const records = 5000
+	var input strings.Builder
+	for i := 0; i < records; i++ {
+		fmt.Fprintf(&input, `{"video_path": "chunk_%05d.mp4", "has_music": true, "song_title": "Song %d"}`+"\n", i, i)
+		if i%100 == 0 {
+			input.WriteString("not json at all\n\n")
+		}
+	}
+
+	var buf bytes.Buffer
+	manager := NewExportManager(NewCSVExporter(&buf))
+	if err := manager.ExportFromReader(strings.NewReader(input.String())); err != nil {
+		t.Fatalf("ExportFromReader: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != records+1 {
+		t.Fatalf("got %d rows, want header + %d records", len(rows), records)
+	}
+	for i, row := range rows[1:] {
+		if want := fmt.Sprintf("chunk_%05d.mp4", i); row[0] != want {
+			t.Fatalf("row %d = %q, want %q (order not preserved)", i, row[0], want)
+		}
+	}
+}
+
+func TestMarkdownExporterWritesTable(t *testing.T) {
+	lines := `{"video_path": "chunk_001.mp4", "has_music": true, "song_title": "Pipe | Dream", "song_artist": "An Artist"}
+`
+	var buf bytes.Buffer
+	manager := NewExportManager(NewMarkdownExporter(&buf))
+	if err := manager.ExportFromString(lines); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	got := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(got) != 3 {
+		t.Fatalf("got %d lines, want header + separator + 1 row:\n%s", len(got), buf.String())
+	}
+	if !strings.HasPrefix(got[0], "| video_path |") {
+		t.Errorf("header = %q", got[0])
+	}
+	if !strings.HasPrefix(got[1], "| --- |") {
+		t.Errorf("separator = %q", got[1])
+	}
+	if !strings.Contains(got[2], `Pipe \| Dream`) {
+		t.Errorf("row %q should escape the pipe in the title", got[2])
+	}
+	for i, line := range got {
+		if strings.Count(line, " | ") != len(csvColumns)-1 {
+			t.Errorf("line %d has wrong column count: %q", i, line)
+		}
+	}
+}
+
+func TestSubtitleExporterWritesCues(t *testing.T) {
+	lines := `{"video_path": "chunk_001.mp4", "transcript": "Hello from the road."}
+{"video_path": "chunk_002.mp4", "transcript": ""}
+{"video_path": "chunk_003.mp4", "transcript": "Still driving."}
+`
+	var buf bytes.Buffer
+	manager := NewExportManager(NewSubtitleExporter(&buf, 30*time.Second))
+	if err := manager.ExportFromString(lines); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	want := "1\n00:00:00,000 --> 00:00:30,000\nHello from the road.\n\n" +
+		"2\n00:01:00,000 --> 00:01:30,000\nStill driving.\n\n"
+	if buf.String() != want {
+		t.Errorf("SRT output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSubtitleExporterHonorsExplicitStart(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := NewSubtitleExporter(&buf, 30*time.Second)
+	record := map[string]interface{}{"transcript": "Late cue.", "start": 3725.5}
+	if err := exporter.WriteRecord(record); err != nil {
+		t.Fatalf("WriteRecord: %v", err)
+	}
+	if !strings.Contains(buf.String(), "01:02:05,500 --> 01:02:35,500") {
+		t.Errorf("cue timing = %q, want explicit start honored", buf.String())
+	}
+}
+
+func TestYouTubeURLExporterDedupsURLs(t *testing.T) {
+	lines := `{"video_path": "chunk_001.mp4", "has_music": true, "urls": {"youtube": "https://youtube.com/watch?v=abc123"}}
+{"video_path": "chunk_002.mp4", "has_music": true, "urls": {"youtube": "https://youtube.com/watch?v=abc123"}}
+{"video_path": "chunk_003.mp4", "has_music": true, "song": {"title": "No URL Song"}}
+{"video_path": "chunk_004.mp4", "has_music": true, "urls": {"youtube": "https://youtube.com/watch?v=def456"}}
+{"video_path": "chunk_005.mp4", "has_music": false}
+`
+	var buf bytes.Buffer
+	manager := NewExportManager(NewYouTubeURLExporter(&buf))
+	if err := manager.ExportFromString(lines); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	got := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	want := []string{
+		"https://youtube.com/watch?v=abc123",
+		"https://youtube.com/watch?v=def456",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(got), len(want), buf.String())
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestJSONExporterWritesPrettyArray(t *testing.T) {
+	var buf bytes.Buffer
+	manager := NewExportManager(NewJSONExporter(&buf))
+	if err := manager.ExportFromString(sampleLines); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	var records []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, buf.String())
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if records[0]["video_path"] != "chunk_001.mp4" || records[0]["has_music"] != true {
+		t.Errorf("first record = %v", records[0])
+	}
+	if !strings.Contains(buf.String(), "\n  ") {
+		t.Errorf("output is not pretty-printed:\n%s", buf.String())
+	}
+}
+
+func TestSetLanguageFiltersRecords(t *testing.T) {
+	var buf bytes.Buffer
+	manager := NewExportManager(NewCSVExporter(&buf))
+	manager.SetLanguage("en")
+
+	input := `{"video_path": "english.mp4", "language": "en"}
+{"video_path": "american.mp4", "language": "en-US"}
+{"video_path": "portuguese.mp4", "language": "pt-BR"}
+{"video_path": "silent.mp4"}
+`
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := -1
+	for i, name := range rows[0] {
+		if name == "language" {
+			col = i
+		}
+	}
+	if col < 0 {
+		t.Fatalf("header %v missing language column", rows[0])
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2 English records", len(rows))
+	}
+	if rows[1][0] != "english.mp4" || rows[2][0] != "american.mp4" {
+		t.Errorf("filtered rows = %v", rows)
+	}
+	if rows[2][col] != "en-US" {
+		t.Errorf("language cell = %q, want en-US", rows[2][col])
+	}
+
+	buf.Reset()
+	manager = NewExportManager(NewCSVExporter(&buf))
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+	if rows, _ := csv.NewReader(&buf).ReadAll(); len(rows) != 5 {
+		t.Errorf("no language tag kept %d rows, want all 4 records", len(rows)-1)
+	}
+}
+
+func TestSetQuoteAllQuotesEveryField(t *testing.T) {
+	var buf bytes.Buffer
+	exporter := NewCSVExporter(&buf)
+	exporter.SetQuoteAll(true)
+
+	manager := NewExportManager(exporter)
+	input := `{"video_path": "chunk_001.mp4", "description": "she said \"hi\"", "has_music": true}`
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want header + 1 record", len(lines))
+	}
+	for _, field := range strings.Split(lines[0], ",") {
+		if !strings.HasPrefix(field, `"`) || !strings.HasSuffix(field, `"`) {
+			t.Errorf("header field %s is not quoted", field)
+		}
+	}
+	for _, want := range []string{`"chunk_001.mp4"`, `"true"`, `"she said ""hi"""`, `,"",`} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("record line %s is missing quoted field %s", lines[1], want)
+		}
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(buf.Bytes())).ReadAll()
+	if err != nil {
+		t.Fatalf("quote-all output is not valid CSV: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want header + 1 record", len(rows))
+	}
+	if rows[1][1] != `she said "hi"` {
+		t.Errorf("embedded quotes not preserved: %q", rows[1][1])
+	}
+
+	buf.Reset()
+	plain := NewCSVExporter(&buf)
+	if err := NewExportManager(plain).ExportFromString(`{"video_path": "chunk_001.mp4"}`); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+	if strings.Contains(buf.String(), `"chunk_001.mp4"`) {
+		t.Errorf("default mode quoted a plain field:\n%s", buf.String())
+	}
+}
+
+func TestDuplicateVideoPathDetection(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+	var logs bytes.Buffer
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	input := `{"video_path": "chunk_001.mp4", "description": "first pass"}
+{"video_path": "chunk_001.mp4", "description": "second pass"}`
+
+	var buf bytes.Buffer
+	manager := NewExportManager(NewCSVExporter(&buf))
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV output: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Errorf("got %d rows, want header + both records (duplicates are kept)", len(rows))
+	}
+	if !strings.Contains(logs.String(), "duplicate video_path") {
+		t.Errorf("no duplicate warning logged:\n%s", logs.String())
+	}
+
+	strict := NewExportManager(NewCSVExporter(&bytes.Buffer{}))
+	strict.SetStrict(true)
+	err = strict.ExportFromString(input)
+	if err == nil {
+		t.Fatal("strict mode should fail on a duplicate video_path")
+	}
+	if !strings.Contains(err.Error(), `duplicate video_path "chunk_001.mp4"`) {
+		t.Errorf("error %q does not name the duplicated path", err)
+	}
+}
@@ -0,0 +1,271 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RelaxedJSONParser extracts a JSON object from model output that is not
+// always well formed: it tries a strict parse first, then pulls the first
+// object literal out of surrounding prose, and finally scrapes key/value
+// pairs with regular expressions as a last resort.
+type RelaxedJSONParser struct {
+	repairTruncated bool
+}
+
+// NewRelaxedJSONParser returns a parser ready for use.
+func NewRelaxedJSONParser() *RelaxedJSONParser {
+	return &RelaxedJSONParser{}
+}
+
+// SetRepairTruncated enables a best-effort repair pass for objects cut off
+// mid-stream: unclosed strings, brackets, and braces are balanced (dropping
+// a trailing incomplete key or value if needed) before re-parsing, which
+// recovers the leading, complete fields. Off by default because the repair
+// silently discards the truncated tail.
+func (p *RelaxedJSONParser) SetRepairTruncated(repair bool) {
+	p.repairTruncated = repair
+}
+
+// fencePattern matches a markdown code fence (with optional language tag)
+// and captures its body.
+var fencePattern = regexp.MustCompile("(?s)```[a-zA-Z]*\\s*(.*?)\\s*```")
+
+// stripCodeFence removes a triple-backtick fence around the payload, along
+// with any prose outside it, so fenced model output takes the fast parse
+// path instead of falling through to the regex heuristics.
+func stripCodeFence(s string) string {
+	if match := fencePattern.FindStringSubmatch(s); match != nil {
+		return match[1]
+	}
+	return s
+}
+
+// objectPattern grabs the outermost brace-delimited blob in a line.
+var objectPattern = regexp.MustCompile(`(?s)\{.*\}`)
+
+// kvStringPattern matches "key": "value" pairs for the manual fallback.
+var kvStringPattern = regexp.MustCompile(`"(\w+)"\s*:\s*"((?:[^"\\]|\\.)*)"`)
+
+// kvScalarPattern matches "key": true/false/number pairs for the manual
+// fallback.
+var kvScalarPattern = regexp.MustCompile(`"(\w+)"\s*:\s*(true|false|-?\d+(?:\.\d+)?)`)
+
+// ParseMethod identifies which strategy ParseJSONWithMethod used for a line,
+// a signal for how often the model emits clean JSON.
+type ParseMethod int
+
+const (
+	// ParseStrict means the line was valid JSON as-is.
+	ParseStrict ParseMethod = iota
+	// ParseFenced means the line was valid JSON once a markdown code fence
+	// was stripped.
+	ParseFenced
+	// ParseExtracted means a brace-delimited object was pulled out of
+	// surrounding prose.
+	ParseExtracted
+	// ParseManual means key/value pairs were scraped with the regex
+	// fallback.
+	ParseManual
+	// ParseRepaired means a truncated object was completed by the opt-in
+	// repair pass.
+	ParseRepaired
+	// ParseFailed means no strategy produced a record.
+	ParseFailed
+)
+
+// String names the method for logs and tallies.
+func (m ParseMethod) String() string {
+	switch m {
+	case ParseStrict:
+		return "strict"
+	case ParseFenced:
+		return "fenced"
+	case ParseExtracted:
+		return "extracted"
+	case ParseManual:
+		return "manual"
+	case ParseRepaired:
+		return "repaired"
+	default:
+		return "failed"
+	}
+}
+
+// ParseJSON parses one line of analysis output into a generic record.
+func (p *RelaxedJSONParser) ParseJSON(line string) (map[string]interface{}, error) {
+	record, _, err := p.ParseJSONWithMethod(line)
+	return record, err
+}
+
+// ParseJSONWithMethod is ParseJSON but additionally reports which parse
+// strategy succeeded.
+func (p *RelaxedJSONParser) ParseJSONWithMethod(line string) (map[string]interface{}, ParseMethod, error) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" {
+		return nil, ParseFailed, fmt.Errorf("empty line")
+	}
+
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(trimmed), &record); err == nil {
+		flattenRecord(record)
+		return record, ParseStrict, nil
+	}
+
+	stripped := stripCodeFence(trimmed)
+	if stripped != trimmed {
+		if err := json.Unmarshal([]byte(stripped), &record); err == nil {
+			flattenRecord(record)
+			return record, ParseFenced, nil
+		}
+	}
+
+	if blob := objectPattern.FindString(stripped); blob != "" {
+		if err := json.Unmarshal([]byte(blob), &record); err == nil {
+			flattenRecord(record)
+			return record, ParseExtracted, nil
+		}
+	}
+
+	if p.repairTruncated {
+		if repaired, ok := repairTruncatedJSON(stripped); ok {
+			flattenRecord(repaired)
+			return repaired, ParseRepaired, nil
+		}
+	}
+
+	record, err := p.parseManually(stripped)
+	if err != nil {
+		return nil, ParseFailed, err
+	}
+	return record, ParseManual, nil
+}
+
+// repairTruncatedJSON tries to complete an object truncated mid-stream. It
+// first closes an unterminated string and any unclosed braces/brackets; if
+// the result still does not parse, it also drops everything after the last
+// complete key/value pair. It returns false when neither attempt produces
+// valid JSON, or when the input was not truncated at all.
+func repairTruncatedJSON(s string) (map[string]interface{}, bool) {
+	var stack []byte
+	inString, escaped := false, false
+	lastComma, lastCommaDepth := -1, 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			stack = append(stack, '}')
+		case '[':
+			stack = append(stack, ']')
+		case '}', ']':
+			if len(stack) > 0 && stack[len(stack)-1] == c {
+				stack = stack[:len(stack)-1]
+			}
+		case ',':
+			lastComma, lastCommaDepth = i, len(stack)
+		}
+	}
+	if len(stack) == 0 && !inString {
+		return nil, false
+	}
+
+	var closers []byte
+	if inString {
+		closers = append(closers, '"')
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		closers = append(closers, stack[i])
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(s+string(closers)), &record); err == nil {
+		return record, true
+	}
+
+	// The tail is an incomplete key or a half-written value; cut back to
+	// the last complete pair and close what was open at that point.
+	if lastComma < 0 || lastCommaDepth > len(stack) {
+		return nil, false
+	}
+	closers = closers[:0]
+	for i := lastCommaDepth - 1; i >= 0; i-- {
+		closers = append(closers, stack[i])
+	}
+	if err := json.Unmarshal([]byte(s[:lastComma]+string(closers)), &record); err == nil {
+		return record, true
+	}
+	return nil, false
+}
+
+// flattenRecord promotes the nested objects the model returns to the flat
+// keys the exporters read: song.title -> song_title, urls.youtube ->
+// youtube_url, and so on. Existing flat keys win over promoted ones.
+func flattenRecord(record map[string]interface{}) {
+	for _, parent := range []string{"song", "web_search_song"} {
+		nested, ok := record[parent].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, value := range nested {
+			flat := parent + "_" + key
+			if _, exists := record[flat]; !exists {
+				record[flat] = value
+			}
+		}
+	}
+	if urls, ok := record["urls"].(map[string]interface{}); ok {
+		for key, value := range urls {
+			flat := key + "_url"
+			if _, exists := record[flat]; !exists {
+				record[flat] = value
+			}
+		}
+	}
+}
+
+// parseManually scrapes whatever key/value pairs it can find from a line
+// that is not valid JSON.
+func (p *RelaxedJSONParser) parseManually(line string) (map[string]interface{}, error) {
+	record := make(map[string]interface{})
+	for _, match := range kvStringPattern.FindAllStringSubmatch(line, -1) {
+		var value string
+		if err := json.Unmarshal([]byte(`"`+match[2]+`"`), &value); err != nil {
+			value = match[2]
+		}
+		record[match[1]] = value
+	}
+	for _, match := range kvScalarPattern.FindAllStringSubmatch(line, -1) {
+		if _, ok := record[match[1]]; ok {
+			continue
+		}
+		switch match[2] {
+		case "true":
+			record[match[1]] = true
+		case "false":
+			record[match[1]] = false
+		default:
+			if f, err := strconv.ParseFloat(match[2], 64); err == nil {
+				record[match[1]] = f
+			}
+		}
+	}
+	if len(record) == 0 {
+		return nil, fmt.Errorf("no recognizable key/value pairs in line")
+	}
+	return record, nil
+}
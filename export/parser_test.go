@@ -0,0 +1,204 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseJSONStrict(t *testing.T) {
+	parser := NewRelaxedJSONParser()
+	record, err := parser.ParseJSON(`{"video_path": "a.mp4", "has_music": true}`)
+	if err != nil {
+		t.Fatalf("ParseJSON: %v", err)
+	}
+	if record["video_path"] != "a.mp4" || record["has_music"] != true {
+		t.Errorf("record = %v", record)
+	}
+}
+
+func TestParseJSONWithSurroundingProse(t *testing.T) {
+	parser := NewRelaxedJSONParser()
+	record, err := parser.ParseJSON(`Here is the analysis: {"video_path": "b.mp4", "description": "a clip"} hope that helps!`)
+	if err != nil {
+		t.Fatalf("ParseJSON: %v", err)
+	}
+	if record["video_path"] != "b.mp4" {
+		t.Errorf("record = %v", record)
+	}
+}
+
+func TestParseJSONStripsCodeFences(t *testing.T) {
+	parser := NewRelaxedJSONParser()
+	tests := []string{
+		"```json {\"video_path\": \"fenced.mp4\", \"has_music\": true} ```",
+		"``` {\"video_path\": \"fenced.mp4\", \"has_music\": true} ```",
+		"Sure, here you go:\n```json\n{\"video_path\": \"fenced.mp4\", \"has_music\": true}\n```\nLet me know if you need more.",
+	}
+	for _, input := range tests {
+		record, err := parser.ParseJSON(input)
+		if err != nil {
+			t.Errorf("ParseJSON(%q): %v", input, err)
+			continue
+		}
+		if record["video_path"] != "fenced.mp4" || record["has_music"] != true {
+			t.Errorf("ParseJSON(%q) = %v", input, record)
+		}
+	}
+}
+
+func TestParseJSONManualFallback(t *testing.T) {
+	parser := NewRelaxedJSONParser()
+	record, err := parser.ParseJSON(`"video_path": "c.mp4", "has_music": true, some trailing garbage`)
+	if err != nil {
+		t.Fatalf("ParseJSON: %v", err)
+	}
+	if record["video_path"] != "c.mp4" {
+		t.Errorf("video_path = %v", record["video_path"])
+	}
+	if record["has_music"] != true {
+		t.Errorf("has_music = %v", record["has_music"])
+	}
+}
+
+func TestParseJSONFlattensNestedObjects(t *testing.T) {
+	parser := NewRelaxedJSONParser()
+	input := `{"video_path": "n.mp4", "song": {"title": "Nested Song", "artist": "Nested Artist"}, "web_search_song": {"title": "Confirmed"}, "urls": {"youtube": "https://youtu.be/x", "spotify": "https://open.spotify.com/track/y"}}`
+	record, err := parser.ParseJSON(input)
+	if err != nil {
+		t.Fatalf("ParseJSON: %v", err)
+	}
+
+	want := map[string]string{
+		"song_title":            "Nested Song",
+		"song_artist":           "Nested Artist",
+		"web_search_song_title": "Confirmed",
+		"youtube_url":           "https://youtu.be/x",
+		"spotify_url":           "https://open.spotify.com/track/y",
+	}
+	for key, value := range want {
+		if record[key] != value {
+			t.Errorf("record[%q] = %v, want %q", key, record[key], value)
+		}
+	}
+}
+
+func TestParseJSONRejectsGarbage(t *testing.T) {
+	parser := NewRelaxedJSONParser()
+	if _, err := parser.ParseJSON("completely unusable output"); err == nil {
+		t.Error("expected an error for unparseable input")
+	}
+}
+
+func TestParseJSONWithMethodReportsStrategy(t *testing.T) {
+	parser := NewRelaxedJSONParser()
+	tests := []struct {
+		input   string
+		want    ParseMethod
+		wantErr bool
+	}{
+		{`{"video_path": "a.mp4", "has_music": true}`, ParseStrict, false},
+		{`{"video_path": "n.mp4", "song": {"title": "Nested Song"}}`, ParseStrict, false},
+		{"```json\n{\"video_path\": \"fenced.mp4\"}\n```", ParseFenced, false},
+		{`Here is the analysis: {"video_path": "b.mp4"} hope that helps!`, ParseExtracted, false},
+		{`"video_path": "c.mp4", "has_music": true, some trailing garbage`, ParseManual, false},
+		{"completely unusable output", ParseFailed, true},
+	}
+	for _, tt := range tests {
+		_, method, err := parser.ParseJSONWithMethod(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseJSONWithMethod(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if method != tt.want {
+			t.Errorf("ParseJSONWithMethod(%q) method = %v, want %v", tt.input, method, tt.want)
+		}
+	}
+}
+
+func TestExportManagerTalliesParseMethods(t *testing.T) {
+	var out bytes.Buffer
+	manager := NewExportManager(NewJSONExporter(&out))
+	input := `{"video_path": "a.mp4"}
+{"video_path": "b.mp4"}
+The model says: {"video_path": "c.mp4"} as requested.
+"video_path": "d.mp4", trailing garbage
+not parseable at all
+`
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+	counts := manager.ParseMethodCounts()
+	want := map[ParseMethod]int{
+		ParseStrict:    2,
+		ParseExtracted: 1,
+		ParseManual:    1,
+		ParseFailed:    1,
+	}
+	for method, n := range want {
+		if counts[method] != n {
+			t.Errorf("counts[%v] = %d, want %d (all counts: %v)", method, counts[method], n, counts)
+		}
+	}
+}
+
+func TestParseJSONRepairsTruncatedObjects(t *testing.T) {
+	truncated := `{"video_path": "chunk_009.mp4", "has_music": true, "song": {"title": "Hey Ju`
+
+	parser := NewRelaxedJSONParser()
+	if _, method, _ := parser.ParseJSONWithMethod(truncated); method == ParseRepaired {
+		t.Fatal("repair ran without being enabled")
+	}
+
+	parser.SetRepairTruncated(true)
+	record, method, err := parser.ParseJSONWithMethod(truncated)
+	if err != nil {
+		t.Fatalf("ParseJSONWithMethod: %v", err)
+	}
+	if method != ParseRepaired {
+		t.Errorf("method = %v, want repaired", method)
+	}
+	if record["video_path"] != "chunk_009.mp4" {
+		t.Errorf("video_path = %v, want the complete leading field recovered", record["video_path"])
+	}
+	if record["has_music"] != true {
+		t.Errorf("has_music = %v, want true", record["has_music"])
+	}
+
+	tests := []struct {
+		name string
+		line string
+		want map[string]interface{}
+	}{
+		{
+			name: "unterminated string value",
+			line: `{"description": "a clip", "transcript": "la la`,
+			want: map[string]interface{}{"description": "a clip", "transcript": "la la"},
+		},
+		{
+			name: "dangling key",
+			line: `{"description": "a clip", "transcr`,
+			want: map[string]interface{}{"description": "a clip"},
+		},
+		{
+			name: "unclosed array",
+			line: `{"description": "a clip", "tags": ["one", "two"`,
+			want: map[string]interface{}{"description": "a clip"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, method, err := parser.ParseJSONWithMethod(tt.line)
+			if err != nil {
+				t.Fatalf("ParseJSONWithMethod: %v", err)
+			}
+			if method != ParseRepaired {
+				t.Errorf("method = %v, want repaired", method)
+			}
+			for key, want := range tt.want {
+				if record[key] != want {
+					t.Errorf("record[%q] = %v, want %v", key, record[key], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestXLSXExporterWritesWorkbook(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "playlist.xlsx")
+	manager := NewExportManager(NewXLSXExporter(path))
+	input := `{"video_path": "chunk_001.mp4", "has_music": true, "song_title": "A Song"}
+{"video_path": "chunk_002.mp4", "has_music": false}
+`
+	if err := manager.ExportFromString(input); err != nil {
+		t.Fatalf("ExportFromString: %v", err)
+	}
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		t.Fatalf("OpenFile: %v", err)
+	}
+	defer f.Close()
+
+	for i, col := range csvColumns {
+		cell, err := excelize.CoordinatesToCellName(i+1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := f.GetCellValue(xlsxSheet, cell)
+		if err != nil {
+			t.Fatalf("GetCellValue(%s): %v", cell, err)
+		}
+		if got != col {
+			t.Errorf("header cell %s = %q, want %q", cell, got, col)
+		}
+	}
+
+	checks := map[string]string{
+		"A2": "chunk_001.mp4",
+		"E2": "true",
+		"F2": "A Song",
+		"A3": "chunk_002.mp4",
+	}
+	for cell, want := range checks {
+		got, err := f.GetCellValue(xlsxSheet, cell)
+		if err != nil {
+			t.Fatalf("GetCellValue(%s): %v", cell, err)
+		}
+		if got != want {
+			t.Errorf("cell %s = %q, want %q", cell, got, want)
+		}
+	}
+}
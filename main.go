@@ -0,0 +1,1180 @@
+// Command pipeline turns long recordings into music playlists: it splits
+// videos into chunks, uploads them to cloud storage, analyzes them with an
+// AI model, and exports the identified songs as a playlist file.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kong"
+
+	"example.com/ai"
+	"example.com/env"
+	"example.com/export"
+	"example.com/playlist"
+	"example.com/storage"
+	"example.com/video"
+)
+
+var cli struct {
+	Config     string           `help:"Pipeline config file (YAML or JSON)." type:"existingfile"`
+	LogLevel   string           `help:"Minimum log level." enum:"debug,info,warn,error" default:"info"`
+	JSONLogs   bool             `help:"Emit logs as JSON instead of text."`
+	Quiet      bool             `help:"Suppress ffmpeg output and status prints."`
+	NoProgress bool             `name:"no-progress" help:"Disable the terminal progress bar."`
+	Version    kong.VersionFlag `help:"Print version information and exit."`
+
+	SplitVideo       SplitVideoCmd       `cmd:"" name:"split-video" help:"Split a time range of a video into fixed-length chunks."`
+	UploadChunks     UploadChunksCmd     `cmd:"" name:"upload-chunks" help:"Upload chunk files to cloud storage."`
+	Analyze          AnalyzeCmd          `cmd:"" name:"analyze" help:"Run AI analysis over local video files."`
+	BuildPlaylist    BuildPlaylistCmd    `cmd:"" name:"build-playlist" help:"Analyze uploaded chunks and emit playlist records."`
+	BuildPlaylistCSV BuildPlaylistCSVCmd `cmd:"" name:"build-playlist-csv" help:"Convert analysis output into a playlist CSV."`
+	Doctor           DoctorCmd           `cmd:"" name:"doctor" help:"Check that the environment has everything the pipeline needs."`
+	DownloadChunks   DownloadChunksCmd   `cmd:"" name:"download-chunks" help:"Download uploaded chunks back from cloud storage."`
+	Info             InfoCmd             `cmd:"" name:"info" help:"Print structured metadata for a video file."`
+	Pipeline         PipelineCmd         `cmd:"" name:"pipeline" help:"Run split, upload, analyze, and CSV export in one go."`
+	SignURL          SignURLCmd          `cmd:"" name:"sign-url" help:"Mint a time-limited download URL for an uploaded object."`
+	VersionCmd       VersionCmd          `cmd:"" name:"version" help:"Print version information."`
+}
+
+func main() {
+	ctx := kong.Parse(&cli,
+		kong.Name("pipeline"),
+		kong.Description("Build music playlists from long recordings."),
+		kong.Vars{"version": versionString()},
+	)
+	ctx.FatalIfErrorf(configureLogging(cli.LogLevel, cli.JSONLogs, os.Stderr))
+	ctx.FatalIfErrorf(ctx.Run())
+}
+
+// version is the release version baked into help and version output.
+const version = "1.0.0"
+
+// versionString renders the release version plus VCS revision and build date
+// when the binary carries build info.
+func versionString() string {
+	revision, date := "unknown", "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.time":
+				date = setting.Value
+			}
+		}
+	}
+	return fmt.Sprintf("pipeline %s (revision %s, built %s)", version, revision, date)
+}
+
+// VersionCmd prints version information, for scripts that prefer a
+// subcommand over the --version flag.
+type VersionCmd struct{}
+
+// Run prints the version string.
+func (c *VersionCmd) Run() error {
+	fmt.Println(versionString())
+	return nil
+}
+
+// configureLogging installs the default slog handler for the chosen level
+// and format.
+func configureLogging(level string, jsonLogs bool, w io.Writer) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("bad log level %q: %w", level, err)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	if jsonLogs {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// SplitVideoCmd cuts a span of the input video into chunks with ffmpeg.
+type SplitVideoCmd struct {
+	In            string `help:"Input video file." required:"" type:"existingfile"`
+	Start         string `help:"Start time (HH:MM:SS, MM:SS, or seconds)." default:"00:00:00"`
+	End           string `help:"End time (HH:MM:SS, MM:SS, or seconds)." xor:"range" required:""`
+	EDL           string `help:"Cue file of start,end[,label] lines to extract instead of fixed-length chunks." xor:"range" required:"" type:"existingfile"`
+	ChunkDuration int    `help:"Chunk length in seconds (config chunk_duration, default 30)." xor:"chunking"`
+	NumChunks     int    `help:"Split the span into exactly this many equal-length chunks instead of fixed-duration ones." xor:"chunking"`
+	OutputDir     string `help:"Directory chunk files are written to (config output_dir, default out)."`
+	StartIndex    int    `help:"Number given to the first chunk, for multi-pass splits." default:"1"`
+	PadWidth      int    `help:"Zero-padding width for chunk numbers." default:"3"`
+	NameTemplate  string `help:"Chunk filename template, e.g. '{{.Base}}_{{.Index}}.mp4'."`
+	ByTimestamp   bool   `help:"Name chunks by start offset, e.g. clip_00-01-30.mp4, instead of a sequential index."`
+	Overwrite     bool   `help:"Write into a non-empty output directory, overwriting colliding chunks." xor:"collision"`
+	Clean         bool   `help:"Remove existing files from the output directory before splitting." xor:"collision"`
+	AudioCodec    string `help:"Re-encode chunk audio with this codec (e.g. aac); the source audio is copied when omitted."`
+	AudioBitrate  string `help:"Audio bitrate when re-encoding, e.g. 192k."`
+	NoReencode    bool   `help:"Copy streams without re-encoding, snapping cuts to the nearest preceding keyframe."`
+	EmbedMetadata bool   `help:"Tag each chunk's container with a title, source/time-range comment, and track number."`
+
+	FFmpegArg []string `name:"ffmpeg-arg" help:"Extra ffmpeg argument appended before the output path; repeat for more. Later flags override built-ins."`
+}
+
+// applyConfig fills in flags the user did not set from the layered config,
+// so env.DefaultVideoConfig and any config file are the single source of
+// truth for splitting defaults.
+func (c *SplitVideoCmd) applyConfig(cfg *env.Config) {
+	if c.ChunkDuration == 0 {
+		c.ChunkDuration = cfg.ChunkDuration
+	}
+	if c.OutputDir == "" {
+		c.OutputDir = cfg.OutputDir
+	}
+}
+
+// Run splits the video.
+func (c *SplitVideoCmd) Run() error {
+	cfg, err := env.LoadConfig(cli.Config)
+	if err != nil {
+		return err
+	}
+	c.applyConfig(cfg)
+
+	processor, err := video.NewVideoProcessor()
+	if err != nil {
+		return err
+	}
+	return c.run(processor)
+}
+
+// run configures the processor from the parsed flags and performs the split.
+// It takes the video.Processor interface so tests can drive the command with
+// a mock.
+func (c *SplitVideoCmd) run(processor video.Processor) error {
+	processor.SetQuiet(cli.Quiet)
+	processor.SetShowProgress(!cli.NoProgress && !cli.Quiet)
+	processor.SetChunkNaming(c.StartIndex, c.PadWidth)
+	if err := processor.SetNameTemplate(c.NameTemplate); err != nil {
+		return err
+	}
+	processor.SetTimestampNames(c.ByTimestamp)
+	switch {
+	case c.Clean:
+		processor.SetOutputDirPolicy(video.OutputDirClean)
+	case c.Overwrite:
+		processor.SetOutputDirPolicy(video.OutputDirOverwrite)
+	}
+	if c.AudioBitrate != "" && c.AudioCodec == "" {
+		return fmt.Errorf("--audio-bitrate needs --audio-codec")
+	}
+	if c.NoReencode && c.AudioCodec != "" {
+		return fmt.Errorf("--no-reencode cannot be combined with --audio-codec")
+	}
+	processor.SetAudioEncoding(c.AudioCodec, c.AudioBitrate)
+	processor.SetCopyMode(c.NoReencode)
+	processor.SetEmbedMetadata(c.EmbedMetadata)
+	processor.SetExtraArgs(c.FFmpegArg)
+	info, err := processor.GetVideoInfo(c.In)
+	if err != nil {
+		return err
+	}
+	if c.EDL != "" {
+		f, err := os.Open(c.EDL)
+		if err != nil {
+			return err
+		}
+		segments, err := video.ParseEDL(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", c.EDL, err)
+		}
+		if !cli.Quiet {
+			fmt.Printf("Extracting %d segments from %s (%s, %dx%d)...\n",
+				len(segments), c.In, info.Duration, info.Width, info.Height)
+		}
+		return processor.SplitVideoFromEDL(c.In, c.OutputDir, segments)
+	}
+	if c.NumChunks > 0 {
+		if !cli.Quiet {
+			fmt.Printf("Splitting %s (%s, %dx%d) into %d equal chunks...\n",
+				c.In, info.Duration, info.Width, info.Height, c.NumChunks)
+		}
+		return processor.SplitVideoCount(c.In, c.OutputDir, c.Start, c.End, c.NumChunks)
+	}
+	if !cli.Quiet {
+		fmt.Printf("Splitting %s (%s, %dx%d) into %ds chunks...\n",
+			c.In, info.Duration, info.Width, info.Height, c.ChunkDuration)
+	}
+	return processor.SplitVideo(c.In, c.OutputDir, c.Start, c.End, c.ChunkDuration)
+}
+
+// UploadChunksCmd uploads local chunk files to a storage bucket.
+type UploadChunksCmd struct {
+	In        []string `help:"Files, globs, or directories to upload; '-' streams stdin to a single object." required:""`
+	StdinName string   `name:"stdin-name" help:"Object name used when uploading from stdin." default:"stdin.bin"`
+	ProjectID string   `help:"GCP project ID (config gcp_project_id)."`
+	Zone      string   `help:"GCP zone (config gcp_zone)."`
+	Bucket    string   `help:"Destination bucket (config gcs_bucket)."`
+	Prefix    string   `help:"Object prefix within the bucket." default:"chunks"`
+	DryRun    bool     `help:"Print each local -> remote mapping without uploading."`
+
+	CredentialsFile string   `help:"Service-account JSON for GCS (default: application default credentials)." type:"existingfile"`
+	Manifest        string   `help:"Write a manifest JSON describing the uploads to this path."`
+	Metadata        []string `help:"Extra object metadata, repeatable." placeholder:"KEY=VALUE"`
+
+	Backend        string `help:"Storage backend." enum:"gcs,minio,local" default:"gcs"`
+	LocalDir       string `help:"Base directory for --backend local." default:"local-storage"`
+	GCSEndpoint    string `help:"Override the GCS endpoint, e.g. a fake-gcs-server emulator." env:"STORAGE_EMULATOR_HOST"`
+	GCSNoAuth      bool   `help:"Skip GCS authentication (for emulators)."`
+	MinIOEndpoint  string `help:"MinIO endpoint (host:port), for --backend minio." default:"localhost:9000"`
+	MinIOAccessKey string `help:"MinIO access key." env:"MINIO_ACCESS_KEY"`
+	MinIOSecretKey string `help:"MinIO secret key." env:"MINIO_SECRET_KEY"`
+	MinIOUseSSL    bool   `help:"Use TLS for the MinIO endpoint."`
+}
+
+// storageOptions selects and configures the storage backend for commands
+// that move chunk files.
+type storageOptions struct {
+	Backend         string
+	ProjectID       string
+	Bucket          string
+	CredentialsFile string
+	LocalDir        string
+	GCSEndpoint     string
+	GCSNoAuth       bool
+	MinIOEndpoint   string
+	MinIOAccessKey  string
+	MinIOSecretKey  string
+	MinIOUseSSL     bool
+}
+
+// newClient constructs the configured storage backend.
+func (o storageOptions) newClient(ctx context.Context) (storage.StorageClient, error) {
+	switch o.Backend {
+	case "gcs":
+		if o.GCSEndpoint != "" {
+			return storage.NewGCSClientWithEndpoint(ctx, o.ProjectID, o.Bucket, o.GCSEndpoint, o.GCSNoAuth)
+		}
+		return storage.NewGCSClientWithCredentialsFile(ctx, o.ProjectID, o.Bucket, o.CredentialsFile)
+	case "minio":
+		return storage.NewMinIOClient(o.MinIOEndpoint, o.MinIOAccessKey, o.MinIOSecretKey, o.Bucket, o.MinIOUseSSL)
+	case "local":
+		return storage.NewLocalStorageClient(o.LocalDir)
+	}
+	return nil, fmt.Errorf("unknown backend %q", o.Backend)
+}
+
+// backendOptions collects the command's backend flags.
+func (c *UploadChunksCmd) backendOptions() storageOptions {
+	return storageOptions{
+		Backend:         c.Backend,
+		ProjectID:       c.ProjectID,
+		Bucket:          c.Bucket,
+		CredentialsFile: c.CredentialsFile,
+		LocalDir:        c.LocalDir,
+		GCSEndpoint:     c.GCSEndpoint,
+		GCSNoAuth:       c.GCSNoAuth,
+		MinIOEndpoint:   c.MinIOEndpoint,
+		MinIOAccessKey:  c.MinIOAccessKey,
+		MinIOSecretKey:  c.MinIOSecretKey,
+		MinIOUseSSL:     c.MinIOUseSSL,
+	}
+}
+
+// applyConfig fills in flags the user did not set from the layered config,
+// and checks that a destination bucket is resolvable for backends that need
+// one.
+func (c *UploadChunksCmd) applyConfig(cfg *env.Config) error {
+	if c.ProjectID == "" {
+		c.ProjectID = cfg.GCPProjectID
+	}
+	if c.Zone == "" {
+		c.Zone = cfg.GCPZone
+	}
+	if c.Bucket == "" {
+		c.Bucket = cfg.GCSBucket
+	}
+	if c.Bucket == "" && c.Backend != "local" {
+		return fmt.Errorf("no bucket: pass --bucket or set gcs_bucket in the config")
+	}
+	return nil
+}
+
+// Run uploads every matched file.
+func (c *UploadChunksCmd) Run() error {
+	cfg, err := env.LoadConfig(cli.Config)
+	if err != nil {
+		return err
+	}
+	if err := c.applyConfig(cfg); err != nil {
+		return err
+	}
+
+	fromStdin := len(c.In) == 1 && c.In[0] == "-"
+	var files []string
+	if !fromStdin {
+		files, err = findFiles(c.In)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("no files matched %v", c.In)
+		}
+	}
+
+	ctx := context.Background()
+	client, err := c.backendOptions().newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	manager := storage.NewUploadManager(client)
+	defer manager.Close()
+	manager.SetShowProgress(!cli.NoProgress && !cli.Quiet && !c.DryRun)
+	if c.DryRun {
+		manager.SetDryRun(os.Stdout)
+	}
+	if len(c.Metadata) > 0 {
+		metadata, err := parseMetadata(c.Metadata)
+		if err != nil {
+			return err
+		}
+		manager.SetObjectMetadata(metadata)
+	}
+	if fromStdin {
+		remotePath := c.StdinName
+		if c.Prefix != "" {
+			remotePath = path.Join(c.Prefix, c.StdinName)
+		}
+		if c.DryRun {
+			fmt.Printf("- -> %s\n", remotePath)
+			return nil
+		}
+		if err := manager.UploadReader(ctx, os.Stdin, -1, remotePath); err != nil {
+			return err
+		}
+	} else if err := manager.UploadFiles(ctx, files, c.Prefix); err != nil {
+		return err
+	}
+	if c.Manifest != "" {
+		return manager.WriteManifest(c.Manifest)
+	}
+	return nil
+}
+
+// parseMetadata converts repeated key=value flag values into a map.
+func parseMetadata(pairs []string) (map[string]string, error) {
+	metadata := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("metadata %q is not a key=value pair", pair)
+		}
+		metadata[key] = value
+	}
+	return metadata, nil
+}
+
+// InfoCmd prints the metadata ffprobe reports for a file, as a quick sanity
+// check before splitting.
+type InfoCmd struct {
+	In   string `help:"Video file to probe." required:"" type:"existingfile"`
+	JSON bool   `help:"Print the metadata as JSON."`
+}
+
+// Run probes the file and prints its metadata.
+func (c *InfoCmd) Run() error {
+	processor, err := video.NewVideoProcessor()
+	if err != nil {
+		return err
+	}
+	info, err := processor.GetVideoInfo(c.In)
+	if err != nil {
+		return err
+	}
+	return c.render(info, os.Stdout)
+}
+
+// render writes the metadata to w in the selected format.
+func (c *InfoCmd) render(info *video.VideoInfo, w io.Writer) error {
+	if c.JSON {
+		out, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", out)
+		return err
+	}
+	fmt.Fprintf(w, "Path:        %s\n", info.Path)
+	fmt.Fprintf(w, "Duration:    %s\n", info.Duration)
+	fmt.Fprintf(w, "Resolution:  %dx%d\n", info.Width, info.Height)
+	fmt.Fprintf(w, "FPS:         %.2f\n", info.FPS)
+	fmt.Fprintf(w, "Video codec: %s\n", info.VideoCodec)
+	fmt.Fprintf(w, "Audio codec: %s\n", info.AudioCodec)
+	fmt.Fprintf(w, "Bit rate:    %d\n", info.BitRate)
+	fmt.Fprintf(w, "Size:        %d bytes\n", info.Size)
+	return nil
+}
+
+// DoctorCmd checks that the environment has everything an expensive pipeline
+// run needs: a loadable config, the AI API key, ffmpeg/ffprobe on PATH, and a
+// reachable bucket.
+type DoctorCmd struct {
+	Bucket    string `help:"Bucket to probe for reachability (config gcs_bucket)."`
+	ProjectID string `help:"GCP project ID for the bucket probe (config gcp_project_id)."`
+}
+
+// checkResult is one pass/fail line of the doctor report.
+type checkResult struct {
+	name string
+	err  error
+}
+
+// Run runs every check and prints the report, returning an error (and thus a
+// non-zero exit) when any check fails.
+func (c *DoctorCmd) Run() error {
+	ctx := context.Background()
+	var results []checkResult
+
+	cfg, err := env.LoadConfig(cli.Config)
+	results = append(results, checkResult{"config loads", err})
+	if cfg != nil {
+		if c.Bucket == "" {
+			c.Bucket = cfg.GCSBucket
+		}
+		if c.ProjectID == "" {
+			c.ProjectID = cfg.GCPProjectID
+		}
+	}
+
+	results = append(results, checkResult{"GEMINI_API_KEY set", checkAPIKey()})
+	results = append(results, checkResult{"ffmpeg and ffprobe on PATH", checkFFmpeg()})
+	results = append(results, checkResult{"bucket reachable", c.checkBucketReachable(ctx)})
+
+	return reportChecks(os.Stdout, results)
+}
+
+// checkAPIKey confirms the Gemini API key is present in the environment.
+func checkAPIKey() error {
+	if os.Getenv("GEMINI_API_KEY") == "" {
+		return fmt.Errorf("GEMINI_API_KEY is not set")
+	}
+	return nil
+}
+
+// checkFFmpeg confirms ffmpeg and ffprobe resolve on PATH.
+func checkFFmpeg() error {
+	_, err := video.NewVideoProcessor()
+	return err
+}
+
+// checkBucketReachable opens a GCS client for the configured bucket and
+// probes it with a stat call.
+func (c *DoctorCmd) checkBucketReachable(ctx context.Context) error {
+	if c.Bucket == "" {
+		return fmt.Errorf("no bucket: pass --bucket or set gcs_bucket in the config")
+	}
+	client, err := storage.NewGCSClient(ctx, c.ProjectID, c.Bucket)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return checkBucket(ctx, client)
+}
+
+// checkBucket confirms the bucket answers a metadata request. Statting a
+// nonexistent object is fine; only a transport or permission error fails.
+func checkBucket(ctx context.Context, client storage.StorageClient) error {
+	_, err := client.StatObject(ctx, "doctor-probe")
+	return err
+}
+
+// reportChecks prints one line per check and returns an error when any
+// check failed.
+func reportChecks(w io.Writer, results []checkResult) error {
+	failed := 0
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+			fmt.Fprintf(w, "FAIL %s: %v\n", result.name, result.err)
+			continue
+		}
+		fmt.Fprintf(w, "ok   %s\n", result.name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(results))
+	}
+	return nil
+}
+
+// DownloadChunksCmd fetches uploaded chunks back from storage so analysis
+// can be re-run on already-uploaded content.
+type DownloadChunksCmd struct {
+	In          string `arg:"" help:"gs://bucket/prefix to download."`
+	OutputDir   string `help:"Directory downloaded chunks are written to." default:"chunks"`
+	Concurrency int    `help:"Parallel downloads." default:"4"`
+	ProjectID   string `help:"GCP project ID."`
+
+	CredentialsFile string `help:"Service-account JSON for GCS (default: application default credentials)." type:"existingfile"`
+}
+
+// Run downloads every object under the prefix.
+func (c *DownloadChunksCmd) Run() error {
+	bucket, prefix, err := storage.ParseGCSPath(c.In)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	client, err := storage.NewGCSClientWithCredentialsFile(ctx, c.ProjectID, bucket, c.CredentialsFile)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	files, err := storage.DownloadObjects(ctx, client, prefix, c.OutputDir, c.Concurrency)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Downloaded %d objects to %s\n", len(files), c.OutputDir)
+	return nil
+}
+
+// PipelineCmd runs the whole chain — split, upload, analyze, export — in one
+// invocation, passing each stage's output to the next in memory.
+type PipelineCmd struct {
+	In            string `help:"Input video file." required:"" type:"existingfile"`
+	Start         string `help:"Start time (HH:MM:SS, MM:SS, or seconds)." default:"00:00:00"`
+	End           string `help:"End time (HH:MM:SS, MM:SS, or seconds)." required:""`
+	ChunkDuration int    `help:"Chunk length in seconds." default:"30"`
+	WorkDir       string `help:"Directory chunk files are written to." default:"out"`
+
+	ProjectID string `help:"GCP project ID."`
+	Bucket    string `help:"Destination bucket."`
+	Prefix    string `help:"Object prefix within the bucket." default:"chunks"`
+
+	CredentialsFile string `help:"Service-account JSON for GCS (default: application default credentials)." type:"existingfile"`
+
+	Backend        string `help:"Storage backend." enum:"gcs,minio,local" default:"gcs"`
+	LocalDir       string `help:"Base directory for --backend local." default:"local-storage"`
+	MinIOEndpoint  string `help:"MinIO endpoint (host:port), for --backend minio." default:"localhost:9000"`
+	MinIOAccessKey string `help:"MinIO access key." env:"MINIO_ACCESS_KEY"`
+	MinIOSecretKey string `help:"MinIO secret key." env:"MINIO_SECRET_KEY"`
+	MinIOUseSSL    bool   `help:"Use TLS for the MinIO endpoint."`
+
+	Out       string `help:"Playlist CSV output path." required:""`
+	Delimiter string `help:"Field delimiter for the CSV output." default:","`
+	MusicOnly bool   `help:"Only include records where music was detected."`
+}
+
+// Run drives the full pipeline.
+func (c *PipelineCmd) Run() error {
+	split := &SplitVideoCmd{In: c.In, Start: c.Start, End: c.End, ChunkDuration: c.ChunkDuration, OutputDir: c.WorkDir}
+	if err := split.Run(); err != nil {
+		return err
+	}
+	chunks, err := findFiles([]string{c.WorkDir})
+	if err != nil {
+		return err
+	}
+	if len(chunks) == 0 {
+		return fmt.Errorf("splitting produced no chunks in %s", c.WorkDir)
+	}
+
+	ctx := context.Background()
+	storageClient, err := storageOptions{
+		Backend:         c.Backend,
+		ProjectID:       c.ProjectID,
+		Bucket:          c.Bucket,
+		CredentialsFile: c.CredentialsFile,
+		LocalDir:        c.LocalDir,
+		MinIOEndpoint:   c.MinIOEndpoint,
+		MinIOAccessKey:  c.MinIOAccessKey,
+		MinIOSecretKey:  c.MinIOSecretKey,
+		MinIOUseSSL:     c.MinIOUseSSL,
+	}.newClient(ctx)
+	if err != nil {
+		return err
+	}
+	aiClient, err := newAnalysisClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer aiClient.Close()
+
+	out, err := os.Create(c.Out)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", c.Out, err)
+	}
+	defer out.Close()
+
+	pathPrefix := ""
+	if c.Backend == "gcs" {
+		pathPrefix = "gs://" + c.Bucket + "/"
+	}
+	csvCmd := &BuildPlaylistCSVCmd{Delimiter: c.Delimiter, MusicOnly: c.MusicOnly}
+	return runPipeline(ctx, chunks, c.Prefix, pathPrefix, storageClient, aiClient, csvCmd, out)
+}
+
+// runPipeline uploads the chunk files, analyzes the uploaded objects, and
+// exports the resulting records through csvCmd.
+func runPipeline(ctx context.Context, chunks []string, prefix, pathPrefix string, storageClient storage.StorageClient, aiClient ai.AIClient, csvCmd *BuildPlaylistCSVCmd, out io.Writer) error {
+	uploads := storage.NewUploadManager(storageClient)
+	defer uploads.Close()
+	if err := uploads.UploadFiles(ctx, chunks, prefix); err != nil {
+		return err
+	}
+
+	var paths []string
+	for _, entry := range uploads.Manifest() {
+		paths = append(paths, pathPrefix+entry.RemotePath)
+	}
+
+	var analysis bytes.Buffer
+	analyzeCmd := &BuildPlaylistCmd{}
+	if err := analyzeCmd.analyze(ctx, aiClient, paths, &analysis); err != nil {
+		return err
+	}
+	return csvCmd.export(&analysis, out)
+}
+
+// findFiles expands a mix of files, globs, and directories into a file list.
+func findFiles(inputs []string) ([]string, error) {
+	var files []string
+	for _, input := range inputs {
+		stat, err := os.Stat(input)
+		switch {
+		case err == nil && stat.IsDir():
+			entries, err := os.ReadDir(input)
+			if err != nil {
+				return nil, fmt.Errorf("reading directory %s: %w", input, err)
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(input, entry.Name()))
+				}
+			}
+		case err == nil:
+			files = append(files, input)
+		default:
+			matches, err := filepath.Glob(input)
+			if err != nil {
+				return nil, fmt.Errorf("bad glob %q: %w", input, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %q", input)
+			}
+			files = append(files, matches...)
+		}
+	}
+	return files, nil
+}
+
+// gcsClientFactory creates a storage client scoped to one bucket; tests
+// substitute a factory returning a mock.
+type gcsClientFactory func(ctx context.Context, bucket string) (storage.StorageClient, error)
+
+// findRemoteObjects is the remote counterpart of findFiles: it expands
+// gs:// inputs, listing the bucket for paths ending in / or * and passing
+// explicit object paths through unchanged. Inputs may span several buckets;
+// one client is opened per bucket. Objects matched by overlapping prefixes
+// are deduplicated, and the result is sorted.
+func findRemoteObjects(ctx context.Context, inputs []string, newClient gcsClientFactory) ([]string, error) {
+	clients := make(map[string]storage.StorageClient)
+	defer func() {
+		for _, client := range clients {
+			client.Close()
+		}
+	}()
+
+	var paths []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, in := range inputs {
+		bucket, object, err := storage.ParseGCSPath(in)
+		if err != nil {
+			return nil, err
+		}
+		if object != "" && !strings.HasSuffix(object, "/") && !strings.HasSuffix(object, "*") {
+			add(in)
+			continue
+		}
+
+		client, ok := clients[bucket]
+		if !ok {
+			var err error
+			client, err = newClient(ctx, bucket)
+			if err != nil {
+				return nil, err
+			}
+			clients[bucket] = client
+		}
+		names, err := client.ListObjects(ctx, strings.TrimSuffix(object, "*"))
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			add("gs://" + bucket + "/" + name)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no objects matched %v", inputs)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// AnalyzeCmd runs AI analysis over local video files without uploading them,
+// which is handy for iterating on prompts against local chunks.
+type AnalyzeCmd struct {
+	In         []string `help:"Files, globs, or directories to analyze." required:""`
+	Normalize  bool     `help:"Loudness-normalize audio (ffmpeg loudnorm) before analysis."`
+	Checkpoint string   `help:"Checkpoint file for resuming interrupted batches."`
+}
+
+// Run analyzes the matched files and writes one JSON record per line to
+// stdout.
+func (c *AnalyzeCmd) Run() error {
+	files, err := findFiles(c.In)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files matched %v", c.In)
+	}
+	if c.Normalize {
+		if files, err = normalizeFiles(files); err != nil {
+			return err
+		}
+	}
+	ctx := context.Background()
+	client, err := newAnalysisClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return c.analyze(ctx, client, files, os.Stdout)
+}
+
+// normalizeFiles runs loudness normalization over each file, writing the
+// normalized copies to a temporary directory and returning their paths.
+func normalizeFiles(files []string) ([]string, error) {
+	processor, err := video.NewVideoProcessor()
+	if err != nil {
+		return nil, err
+	}
+	processor.SetQuiet(cli.Quiet)
+	dir, err := os.MkdirTemp("", "normalized-")
+	if err != nil {
+		return nil, err
+	}
+	normalized := make([]string, 0, len(files))
+	for _, file := range files {
+		out := filepath.Join(dir, filepath.Base(file))
+		lufs, err := processor.NormalizeAudio(file, out)
+		if err != nil {
+			return nil, fmt.Errorf("normalizing %s: %w", file, err)
+		}
+		slog.Info("normalized audio", "input", file, "measured_lufs", lufs)
+		normalized = append(normalized, out)
+	}
+	return normalized, nil
+}
+
+// analyze runs the analysis over the resolved files and streams NDJSON
+// records to w.
+func (c *AnalyzeCmd) analyze(ctx context.Context, client ai.AIClient, paths []string, w io.Writer) error {
+	manager := ai.NewAnalysisManager(client)
+	// Remux is best effort: without ffmpeg installed, non-mp4 inputs are
+	// sent as-is and rejected by the API instead.
+	if processor, err := video.NewVideoProcessor(); err == nil {
+		manager.SetRemux(processor)
+	}
+	if c.Checkpoint != "" {
+		if err := manager.SetCheckpoint(c.Checkpoint); err != nil {
+			return err
+		}
+	}
+	return manager.AnalyzeVideosStream(ctx, paths, w)
+}
+
+// BuildPlaylistCmd analyzes uploaded chunks and emits playlist records.
+type BuildPlaylistCmd struct {
+	In              []string `help:"gs:// paths of chunks to analyze; a path ending in / or * is expanded as a prefix." required:""`
+	ProjectID       string   `help:"GCP project ID used when expanding prefixes."`
+	Out             string   `help:"Write NDJSON records to this file instead of stdout, flushing each record to disk."`
+	Validate        bool     `name:"validate-json" help:"Validate analysis output records."`
+	SpotifyPlaylist string   `name:"spotify-playlist" help:"Also add the identified songs to this Spotify playlist."`
+	Checkpoint      string   `help:"Checkpoint file for resuming interrupted batches."`
+}
+
+// Run analyzes the resolved chunks and writes one JSON record per line to
+// stdout.
+func (c *BuildPlaylistCmd) Run() error {
+	cfg, err := env.LoadConfig(cli.Config)
+	if err != nil {
+		return err
+	}
+	if c.ProjectID == "" {
+		c.ProjectID = cfg.GCPProjectID
+	}
+
+	ctx := context.Background()
+	paths, err := c.resolveInputs(ctx)
+	if err != nil {
+		return err
+	}
+	client, err := newAnalysisClient(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	out, closeOut, err := c.openOutput()
+	if err != nil {
+		return err
+	}
+	if c.SpotifyPlaylist == "" {
+		err := c.analyze(ctx, client, paths, out)
+		if cerr := closeOut(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+
+	// Buffer the stream so the records can also be pushed to Spotify.
+	var buf bytes.Buffer
+	if err := c.analyze(ctx, client, paths, &buf); err != nil {
+		closeOut()
+		return err
+	}
+	_, err = out.Write(buf.Bytes())
+	if cerr := closeOut(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return c.pushToSpotify(ctx, &buf)
+}
+
+// openOutput returns the NDJSON destination: the --out file with every
+// record flushed to disk as it lands, or stdout when no file was given.
+func (c *BuildPlaylistCmd) openOutput() (io.Writer, func() error, error) {
+	if c.Out == "" {
+		return os.Stdout, func() error { return nil }, nil
+	}
+	f, err := os.Create(c.Out)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &syncingWriter{f: f}, f.Close, nil
+}
+
+// syncingWriter forwards writes to the underlying file and syncs after each
+// one, so partial NDJSON output survives a crash mid-batch.
+type syncingWriter struct {
+	f *os.File
+}
+
+func (w *syncingWriter) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, w.f.Sync()
+}
+
+// pushToSpotify re-reads the NDJSON records and adds every identified song
+// to the configured Spotify playlist.
+func (c *BuildPlaylistCmd) pushToSpotify(ctx context.Context, records io.Reader) error {
+	clientID := os.Getenv("SPOTIFY_CLIENT_ID")
+	clientSecret := os.Getenv("SPOTIFY_CLIENT_SECRET")
+	userToken := os.Getenv("SPOTIFY_USER_TOKEN")
+	if clientID == "" || clientSecret == "" || userToken == "" {
+		return fmt.Errorf("--spotify-playlist needs SPOTIFY_CLIENT_ID, SPOTIFY_CLIENT_SECRET, and SPOTIFY_USER_TOKEN")
+	}
+
+	var songs []ai.Song
+	decoder := json.NewDecoder(records)
+	for decoder.More() {
+		var record ai.VideoAnalysisResponse
+		if err := decoder.Decode(&record); err != nil {
+			return err
+		}
+		if song := record.BestSong(); record.HasMusic && song.Title != "" {
+			songs = append(songs, song)
+		}
+	}
+
+	client := playlist.NewSpotifyClient(clientID, clientSecret, userToken)
+	added, err := client.BuildPlaylist(ctx, c.SpotifyPlaylist, songs)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Added %d tracks to Spotify playlist %q\n", added, c.SpotifyPlaylist)
+	return nil
+}
+
+// newAnalysisClient picks the AI client configured via the environment,
+// falling back to the mock so the pipeline can run without an API key.
+func newAnalysisClient(ctx context.Context) (ai.AIClient, error) {
+	if os.Getenv("GEMINI_API_KEY") == "" && os.Getenv("OPENAI_API_KEY") == "" {
+		fmt.Fprintln(os.Stderr, "warning: GEMINI_API_KEY is not set, using the mock AI client")
+		return ai.NewMockAIClient(), nil
+	}
+	return ai.NewAIClientFromEnv(ctx)
+}
+
+// resolveInputs expands gs:// prefixes (paths ending in / or *) into
+// concrete object paths, passing explicit object paths through unchanged.
+func (c *BuildPlaylistCmd) resolveInputs(ctx context.Context) ([]string, error) {
+	return findRemoteObjects(ctx, c.In, func(ctx context.Context, bucket string) (storage.StorageClient, error) {
+		return storage.NewGCSClient(ctx, c.ProjectID, bucket)
+	})
+}
+
+// analyze runs the analysis over paths and streams NDJSON records to w.
+func (c *BuildPlaylistCmd) analyze(ctx context.Context, client ai.AIClient, paths []string, w io.Writer) error {
+	manager := ai.NewAnalysisManager(client)
+	if c.Checkpoint != "" {
+		if err := manager.SetCheckpoint(c.Checkpoint); err != nil {
+			return err
+		}
+	}
+	tee := &analysisTee{w: w}
+	if !c.Validate {
+		err := manager.AnalyzeVideosStream(ctx, paths, tee)
+		tee.summarize()
+		return err
+	}
+
+	// Buffer the stream so every record can be checked before any output
+	// is committed.
+	var buf bytes.Buffer
+	tee.w = &buf
+	if err := manager.AnalyzeVideosStream(ctx, paths, tee); err != nil {
+		return err
+	}
+	var invalid []error
+	for i, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		if err := validateAnalysisRecord([]byte(line)); err != nil {
+			invalid = append(invalid, fmt.Errorf("invalid analysis record on line %d: %w", i+1, err))
+		}
+	}
+	if len(invalid) > 0 {
+		return errors.Join(invalid...)
+	}
+	tee.summarize()
+	_, err := io.Copy(w, &buf)
+	return err
+}
+
+// analysisTee passes NDJSON records through to the underlying writer while
+// decoding each complete line, so a batch summary can be printed at the end
+// without buffering the stream twice.
+type analysisTee struct {
+	w         io.Writer
+	partial   bytes.Buffer
+	responses []*ai.VideoAnalysisResponse
+}
+
+func (t *analysisTee) Write(p []byte) (int, error) {
+	n, err := t.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	t.partial.Write(p[:n])
+	for {
+		line, err := t.partial.ReadBytes('\n')
+		if err != nil {
+			// Incomplete trailing line; keep it for the next write.
+			t.partial.Write(line)
+			break
+		}
+		var record ai.VideoAnalysisResponse
+		if json.Unmarshal(line, &record) == nil {
+			t.responses = append(t.responses, &record)
+		}
+	}
+	return n, nil
+}
+
+// summarize prints the batch statistics to stderr, keeping NDJSON stdout
+// clean.
+func (t *analysisTee) summarize() {
+	if cli.Quiet {
+		return
+	}
+	fmt.Fprintln(os.Stderr, ai.Summarize(t.responses))
+}
+
+// validateAnalysisRecord checks that one NDJSON line conforms to the
+// ai.VideoAnalysisResponse schema: it must decode with the expected field
+// types and carry the fields every downstream exporter depends on.
+func validateAnalysisRecord(line []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.DisallowUnknownFields()
+	var record ai.VideoAnalysisResponse
+	if err := dec.Decode(&record); err != nil {
+		return err
+	}
+	if record.VideoPath == "" {
+		return fmt.Errorf("missing required field %q", "video_path")
+	}
+	return nil
+}
+
+// SignURLCmd mints a time-limited download URL for an uploaded object.
+type SignURLCmd struct {
+	Path      string        `arg:"" help:"gs://bucket/object path of the object."`
+	TTL       time.Duration `help:"How long the URL stays valid." default:"15m"`
+	ProjectID string        `help:"GCP project ID."`
+}
+
+// Run prints the signed URL.
+func (c *SignURLCmd) Run() error {
+	if c.TTL <= 0 {
+		return fmt.Errorf("--ttl must be positive, got %v", c.TTL)
+	}
+	bucket, object, err := storage.ParseGCSPath(c.Path)
+	if err != nil {
+		return err
+	}
+	if object == "" {
+		return fmt.Errorf("path %q must name a bucket and an object", c.Path)
+	}
+
+	ctx := context.Background()
+	client, err := storage.NewGCSClient(ctx, c.ProjectID, bucket)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	url, err := client.SignedURL(ctx, object, c.TTL)
+	if err != nil {
+		return err
+	}
+	fmt.Println(url)
+	return nil
+}
+
+// BuildPlaylistCSVCmd converts analysis output into a playlist CSV.
+type BuildPlaylistCSVCmd struct {
+	In            string  `help:"Analysis output file (JSON lines); '-' or omitted with piped input reads stdin."`
+	Out           string  `help:"Output file (stdout when omitted)."`
+	Format        string  `help:"Output format." enum:"csv,json,m3u,md,srt,urls,xlsx" default:"csv"`
+	Delimiter     string  `help:"Field delimiter for CSV output, e.g. '\\t' for TSV." default:","`
+	QuoteAll      bool    `help:"Wrap every CSV field in double quotes instead of quoting only when needed."`
+	MusicOnly     bool    `help:"Only include records where music was detected."`
+	MinConfidence float64 `name:"min-confidence" help:"Drop records whose song confidence is below this threshold." default:"0"`
+	Language      string  `help:"Keep only records whose detected language matches this BCP-47 tag, e.g. en."`
+	Strict        bool    `help:"Fail on malformed input lines instead of skipping them."`
+	ChunkDuration int     `help:"Chunk length in seconds, used for SRT cue timing." default:"30"`
+}
+
+// Run converts the analysis output into CSV.
+func (c *BuildPlaylistCSVCmd) Run() error {
+	if c.In == "" && !stdinIsPiped() {
+		return fmt.Errorf("no input: pass --in or pipe analysis output on stdin")
+	}
+	out := io.Writer(os.Stdout)
+	// The xlsx exporter saves to c.Out itself rather than streaming to a
+	// writer.
+	if c.Out != "" && c.Format != "xlsx" {
+		f, err := os.Create(c.Out)
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", c.Out, err)
+		}
+		defer f.Close()
+		out = f
+	}
+	return c.export(os.Stdin, out)
+}
+
+// export runs the conversion, reading from c.In (or stdin when it is "-" or
+// empty) and writing CSV to w.
+func (c *BuildPlaylistCSVCmd) export(stdin io.Reader, w io.Writer) error {
+	var exporter export.Exporter
+	switch c.Format {
+	case "", "csv":
+		delimiter, err := parseDelimiter(c.Delimiter)
+		if err != nil {
+			return err
+		}
+		csvExporter := export.NewCSVExporter(w)
+		csvExporter.SetDelimiter(delimiter)
+		csvExporter.SetQuoteAll(c.QuoteAll)
+		exporter = csvExporter
+	case "json":
+		exporter = export.NewJSONExporter(w)
+	case "m3u":
+		exporter = export.NewM3UExporter(w)
+	case "md":
+		exporter = export.NewMarkdownExporter(w)
+	case "srt":
+		exporter = export.NewSubtitleExporter(w, time.Duration(c.ChunkDuration)*time.Second)
+	case "urls":
+		exporter = export.NewYouTubeURLExporter(w)
+	case "xlsx":
+		if c.Out == "" {
+			return fmt.Errorf("--format xlsx writes a workbook file: pass --out")
+		}
+		exporter = export.NewXLSXExporter(c.Out)
+	default:
+		return fmt.Errorf("unknown format %q", c.Format)
+	}
+	manager := export.NewExportManager(exporter)
+	manager.SetStrict(c.Strict)
+	manager.SetMinConfidence(c.MinConfidence)
+	manager.SetLanguage(c.Language)
+	if c.MusicOnly {
+		manager.SetFilter(export.MusicOnlyFilter)
+	}
+
+	if c.In != "" && c.In != "-" {
+		return manager.ExportFromFile(c.In)
+	}
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return fmt.Errorf("reading stdin: %w", err)
+	}
+	return manager.ExportFromString(string(data))
+}
+
+// stdinIsPiped reports whether stdin carries piped input rather than an
+// interactive terminal.
+func stdinIsPiped() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice == 0
+}
+
+// parseDelimiter converts a flag value like "," or "\\t" into a rune.
+func parseDelimiter(s string) (rune, error) {
+	switch s {
+	case "\\t", "tab":
+		s = "\t"
+	}
+	runes := []rune(s)
+	if len(runes) != 1 {
+		return 0, fmt.Errorf("delimiter must be a single character, got %q", s)
+	}
+	return runes[0], nil
+}
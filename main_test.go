@@ -0,0 +1,659 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"example.com/ai"
+	"example.com/env"
+	"example.com/storage"
+	"example.com/video"
+)
+
+func TestBuildPlaylistCSVCmdExportsFile(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "analysis.ndjson")
+	lines := `{"video_path": "chunk_001.mp4", "has_music": true, "song_title": "A Song"}
+not parseable at all
+{"video_path": "chunk_002.mp4", "has_music": false}
+`
+	if err := os.WriteFile(in, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &BuildPlaylistCSVCmd{In: in, Delimiter: ","}
+	var buf bytes.Buffer
+	if err := cmd.export(nil, &buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV output: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header + 2 records", len(rows))
+	}
+	if rows[1][0] != "chunk_001.mp4" || rows[1][5] != "A Song" {
+		t.Errorf("first record = %v", rows[1])
+	}
+}
+
+func TestBuildPlaylistCSVCmdReadsStdin(t *testing.T) {
+	cmd := &BuildPlaylistCSVCmd{In: "-", Delimiter: ","}
+	stdin := bytes.NewBufferString(`{"video_path": "piped.mp4", "has_music": true}` + "\n")
+	var buf bytes.Buffer
+	if err := cmd.export(stdin, &buf); err != nil {
+		t.Fatalf("export: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1][0] != "piped.mp4" {
+		t.Errorf("rows = %v, want header + piped record", rows)
+	}
+}
+
+func TestBuildPlaylistCmdEmitsNDJSON(t *testing.T) {
+	mock := ai.NewMockAIClient()
+	mock.Response = &ai.VideoAnalysisResponse{
+		HasMusic: true,
+		Song:     ai.Song{Title: "A Song", Artist: "An Artist"},
+	}
+
+	paths := []string{
+		"gs://my-bucket/chunks/chunk_001.mp4",
+		"gs://my-bucket/chunks/chunk_002.mp4",
+	}
+	cmd := &BuildPlaylistCmd{In: paths, Validate: true}
+	var buf bytes.Buffer
+	if err := cmd.analyze(context.Background(), mock, paths, &buf); err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != len(paths) {
+		t.Fatalf("got %d NDJSON lines, want %d", len(lines), len(paths))
+	}
+	seen := map[string]bool{}
+	for _, line := range lines {
+		var record ai.VideoAnalysisResponse
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %q is not valid JSON: %v", line, err)
+		}
+		if !record.HasMusic || record.Song.Title != "A Song" {
+			t.Errorf("record = %+v, want the mock response", record)
+		}
+		seen[record.VideoPath] = true
+	}
+	for _, path := range paths {
+		if !seen[path] {
+			t.Errorf("no record emitted for %s", path)
+		}
+	}
+}
+
+func TestSplitVideoCmdRunDrivesProcessor(t *testing.T) {
+	quiet := cli.Quiet
+	cli.Quiet = true
+	defer func() { cli.Quiet = quiet }()
+
+	mock := video.NewMockVideoProcessor()
+	cmd := &SplitVideoCmd{
+		In:            "roadtrip.mp4",
+		Start:         "00:00:30",
+		End:           "01:00",
+		ChunkDuration: 45,
+		OutputDir:     "chunks",
+		StartIndex:    1,
+		PadWidth:      3,
+	}
+	if err := cmd.run(mock); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if len(mock.InfoCalls) != 1 || mock.InfoCalls[0] != "roadtrip.mp4" {
+		t.Errorf("InfoCalls = %v, want the input probed once", mock.InfoCalls)
+	}
+	if len(mock.SplitCalls) != 1 {
+		t.Fatalf("got %d SplitVideo calls, want 1", len(mock.SplitCalls))
+	}
+	want := video.SplitCall{
+		InputFile:     "roadtrip.mp4",
+		OutputDir:     "chunks",
+		StartTime:     "00:00:30",
+		EndTime:       "01:00",
+		ChunkDuration: 45,
+	}
+	if mock.SplitCalls[0] != want {
+		t.Errorf("SplitVideo call = %+v, want %+v", mock.SplitCalls[0], want)
+	}
+	if mock.StartIndex != 1 || mock.PadWidth != 3 {
+		t.Errorf("chunk naming = (%d, %d), want (1, 3)", mock.StartIndex, mock.PadWidth)
+	}
+}
+
+func TestReportChecks(t *testing.T) {
+	var buf bytes.Buffer
+	results := []checkResult{
+		{"config loads", nil},
+		{"GEMINI_API_KEY set", fmt.Errorf("GEMINI_API_KEY is not set")},
+		{"bucket reachable", nil},
+	}
+	err := reportChecks(&buf, results)
+	if err == nil || !strings.Contains(err.Error(), "1 of 3 checks failed") {
+		t.Fatalf("reportChecks = %v, want a 1-of-3 failure", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != len(results) {
+		t.Fatalf("report has %d lines, want %d", len(lines), len(results))
+	}
+	if lines[0] != "ok   config loads" {
+		t.Errorf("line 1 = %q, want ok config loads", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "FAIL GEMINI_API_KEY set: ") {
+		t.Errorf("line 2 = %q, want a FAIL line with the reason", lines[1])
+	}
+	if lines[2] != "ok   bucket reachable" {
+		t.Errorf("line 3 = %q, want ok bucket reachable", lines[2])
+	}
+
+	buf.Reset()
+	if err := reportChecks(&buf, results[:1]); err != nil {
+		t.Errorf("reportChecks with all passing = %v, want nil", err)
+	}
+}
+
+func TestCheckBucket(t *testing.T) {
+	mock := storage.NewMockStorageClient()
+	if err := checkBucket(context.Background(), mock); err != nil {
+		t.Errorf("checkBucket against a healthy client = %v, want nil", err)
+	}
+	mock.Err = fmt.Errorf("dial tcp: no route to host")
+	if err := checkBucket(context.Background(), mock); err == nil {
+		t.Error("checkBucket should surface the client error")
+	}
+}
+
+func TestBuildPlaylistCmdWritesNDJSONFile(t *testing.T) {
+	mock := ai.NewMockAIClient()
+	paths := []string{
+		"gs://my-bucket/chunks/chunk_001.mp4",
+		"gs://my-bucket/chunks/chunk_002.mp4",
+		"gs://my-bucket/chunks/chunk_003.mp4",
+	}
+	outPath := filepath.Join(t.TempDir(), "records.ndjson")
+	cmd := &BuildPlaylistCmd{In: paths, Out: outPath}
+
+	out, closeOut, err := cmd.openOutput()
+	if err != nil {
+		t.Fatalf("openOutput: %v", err)
+	}
+	if err := cmd.analyze(context.Background(), mock, paths, out); err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if err := closeOut(); err != nil {
+		t.Fatalf("closing output: %v", err)
+	}
+
+	data, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) != len(paths) {
+		t.Fatalf("got %d NDJSON lines, want %d", len(lines), len(paths))
+	}
+	for _, line := range lines {
+		var record ai.VideoAnalysisResponse
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %q is not valid JSON: %v", line, err)
+		}
+		if record.VideoPath == "" {
+			t.Errorf("record %q has no video_path", line)
+		}
+	}
+}
+
+func TestValidateAnalysisRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		wantErr bool
+	}{
+		{
+			name: "valid record",
+			line: `{"video_path": "gs://b/chunk_001.mp4", "description": "a clip", "has_music": false}`,
+		},
+		{
+			name:    "missing video_path",
+			line:    `{"description": "a clip", "has_music": true}`,
+			wantErr: true,
+		},
+		{
+			name:    "wrong type for has_music",
+			line:    `{"video_path": "gs://b/chunk_001.mp4", "has_music": "yes"}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown field",
+			line:    `{"video_path": "gs://b/chunk_001.mp4", "mood": "upbeat"}`,
+			wantErr: true,
+		},
+		{
+			name:    "not JSON",
+			line:    `<<<garbage>>>`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAnalysisRecord([]byte(tt.line))
+			if tt.wantErr && err == nil {
+				t.Errorf("validateAnalysisRecord(%q) = nil, want error", tt.line)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("validateAnalysisRecord(%q) = %v, want nil", tt.line, err)
+			}
+		})
+	}
+}
+
+func TestBuildPlaylistCmdResolveInputsRejectsLocalPaths(t *testing.T) {
+	cmd := &BuildPlaylistCmd{In: []string{"chunks/chunk_001.mp4"}}
+	if _, err := cmd.resolveInputs(context.Background()); err == nil {
+		t.Error("expected an error for a non-gs:// input")
+	}
+}
+
+func TestSplitVideoCmdDefaultsFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipeline.yaml")
+	if err := os.WriteFile(path, []byte("chunk_duration: 45\noutput_dir: chunks\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := env.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	cmd := &SplitVideoCmd{}
+	cmd.applyConfig(cfg)
+	if cmd.ChunkDuration != 45 {
+		t.Errorf("ChunkDuration = %d, want 45 from config", cmd.ChunkDuration)
+	}
+	if cmd.OutputDir != "chunks" {
+		t.Errorf("OutputDir = %q, want %q from config", cmd.OutputDir, "chunks")
+	}
+
+	explicit := &SplitVideoCmd{ChunkDuration: 10, OutputDir: "elsewhere"}
+	explicit.applyConfig(cfg)
+	if explicit.ChunkDuration != 10 || explicit.OutputDir != "elsewhere" {
+		t.Errorf("explicit flags overridden: got %d %q", explicit.ChunkDuration, explicit.OutputDir)
+	}
+}
+
+func TestUploadChunksCmdDefaultsFromConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pipeline.yaml")
+	config := "gcp_project_id: my-project\ngcp_zone: us-central1-a\ngcs_bucket: my-bucket\n"
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GCP_PROJECT_ID", "")
+	t.Setenv("GCP_ZONE", "")
+	t.Setenv("GCS_BUCKET", "")
+	cfg, err := env.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+
+	cmd := &UploadChunksCmd{Backend: "gcs"}
+	if err := cmd.applyConfig(cfg); err != nil {
+		t.Fatalf("applyConfig: %v", err)
+	}
+	if cmd.ProjectID != "my-project" || cmd.Zone != "us-central1-a" || cmd.Bucket != "my-bucket" {
+		t.Errorf("got %q %q %q, want config defaults", cmd.ProjectID, cmd.Zone, cmd.Bucket)
+	}
+
+	explicit := &UploadChunksCmd{Backend: "gcs", Bucket: "other-bucket"}
+	if err := explicit.applyConfig(cfg); err != nil {
+		t.Fatalf("applyConfig: %v", err)
+	}
+	if explicit.Bucket != "other-bucket" {
+		t.Errorf("Bucket = %q, want explicit flag to win", explicit.Bucket)
+	}
+
+	missing := &UploadChunksCmd{Backend: "gcs"}
+	if err := missing.applyConfig(&env.Config{}); err == nil {
+		t.Error("expected an error when no bucket is resolvable")
+	}
+	local := &UploadChunksCmd{Backend: "local"}
+	if err := local.applyConfig(&env.Config{}); err != nil {
+		t.Errorf("local backend should not require a bucket: %v", err)
+	}
+}
+
+func TestFindFilesExpandsGlobsAndDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"chunk_001.mp4", "chunk_002.mp4", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := findFiles([]string{filepath.Join(dir, "chunk_*.mp4")})
+	if err != nil {
+		t.Fatalf("findFiles glob: %v", err)
+	}
+	if len(files) != 2 {
+		t.Errorf("glob matched %d files, want 2: %v", len(files), files)
+	}
+
+	files, err = findFiles([]string{dir})
+	if err != nil {
+		t.Fatalf("findFiles directory: %v", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("directory matched %d files, want 3: %v", len(files), files)
+	}
+
+	if _, err := findFiles([]string{filepath.Join(dir, "missing_*.mp4")}); err == nil {
+		t.Error("expected an error for a glob with no matches")
+	}
+}
+
+func TestFindRemoteObjectsExpandsPrefixes(t *testing.T) {
+	mock := storage.NewMockStorageClient()
+	mock.Objects = []string{"chunks/chunk_001.mp4", "chunks/chunk_002.mp4", "other/readme.txt"}
+	factory := func(ctx context.Context, bucket string) (storage.StorageClient, error) {
+		if bucket != "my-bucket" {
+			t.Errorf("factory called for bucket %q", bucket)
+		}
+		return mock, nil
+	}
+
+	paths, err := findRemoteObjects(context.Background(), []string{"gs://my-bucket/chunks/*"}, factory)
+	if err != nil {
+		t.Fatalf("findRemoteObjects: %v", err)
+	}
+	want := []string{
+		"gs://my-bucket/chunks/chunk_001.mp4",
+		"gs://my-bucket/chunks/chunk_002.mp4",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("paths[%d] = %q, want %q", i, paths[i], want[i])
+		}
+	}
+
+	// Explicit object paths pass through without any listing.
+	paths, err = findRemoteObjects(context.Background(), []string{"gs://my-bucket/chunks/chunk_009.mp4"}, factory)
+	if err != nil {
+		t.Fatalf("findRemoteObjects passthrough: %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "gs://my-bucket/chunks/chunk_009.mp4" {
+		t.Errorf("paths = %v, want the input unchanged", paths)
+	}
+}
+
+func TestFindRemoteObjectsDedupesAcrossPrefixes(t *testing.T) {
+	mocks := map[string]*storage.MockStorageClient{
+		"bucket-a": storage.NewMockStorageClient(),
+		"bucket-b": storage.NewMockStorageClient(),
+	}
+	mocks["bucket-a"].Objects = []string{
+		"chunks/chunk_002.mp4",
+		"chunks/chunk_001.mp4",
+	}
+	mocks["bucket-b"].Objects = []string{"chunks/chunk_003.mp4"}
+	factory := func(ctx context.Context, bucket string) (storage.StorageClient, error) {
+		mock, ok := mocks[bucket]
+		if !ok {
+			t.Fatalf("factory called for unexpected bucket %q", bucket)
+		}
+		return mock, nil
+	}
+
+	// The second bucket-a prefix overlaps the first; the explicit path
+	// duplicates a listed object.
+	paths, err := findRemoteObjects(context.Background(), []string{
+		"gs://bucket-a/chunks/",
+		"gs://bucket-a/chunks/chunk_*",
+		"gs://bucket-b/chunks/",
+		"gs://bucket-a/chunks/chunk_001.mp4",
+	}, factory)
+	if err != nil {
+		t.Fatalf("findRemoteObjects: %v", err)
+	}
+	want := []string{
+		"gs://bucket-a/chunks/chunk_001.mp4",
+		"gs://bucket-a/chunks/chunk_002.mp4",
+		"gs://bucket-b/chunks/chunk_003.mp4",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("paths[%d] = %q, want %q", i, paths[i], want[i])
+		}
+	}
+}
+
+func TestAnalyzeCmdStreamsLocalFiles(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for _, name := range []string{"chunk_001.mp4", "chunk_002.mp4"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("chunk data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+
+	mock := ai.NewMockAIClient()
+	cmd := &AnalyzeCmd{In: []string{dir}}
+	var buf bytes.Buffer
+	if err := cmd.analyze(context.Background(), mock, files, &buf); err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != len(files) {
+		t.Fatalf("got %d NDJSON lines, want %d", len(lines), len(files))
+	}
+	for _, line := range lines {
+		var record ai.VideoAnalysisResponse
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %q is not valid JSON: %v", line, err)
+		}
+	}
+	if mock.AnalyzeCount != len(files) {
+		t.Errorf("AnalyzeCount = %d, want %d", mock.AnalyzeCount, len(files))
+	}
+}
+
+func TestRunPipelineChainsStages(t *testing.T) {
+	dir := t.TempDir()
+	var chunks []string
+	for _, name := range []string{"chunk_001.mp4", "chunk_002.mp4"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("chunk data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, path)
+	}
+
+	storageMock := storage.NewMockStorageClient()
+	aiMock := ai.NewMockAIClient()
+	aiMock.Response = &ai.VideoAnalysisResponse{
+		HasMusic: true,
+		Song:     ai.Song{Title: "A Song", Artist: "An Artist"},
+	}
+
+	csvCmd := &BuildPlaylistCSVCmd{Delimiter: ","}
+	var out bytes.Buffer
+	err := runPipeline(context.Background(), chunks, "chunks", "gs://test-bucket/", storageMock, aiMock, csvCmd, &out)
+	if err != nil {
+		t.Fatalf("runPipeline: %v", err)
+	}
+
+	if storageMock.UploadCount != len(chunks) {
+		t.Errorf("UploadCount = %d, want %d", storageMock.UploadCount, len(chunks))
+	}
+	if aiMock.AnalyzeCount != len(chunks) {
+		t.Errorf("AnalyzeCount = %d, want %d", aiMock.AnalyzeCount, len(chunks))
+	}
+	for _, path := range aiMock.AnalyzedPaths {
+		if !strings.HasPrefix(path, "gs://test-bucket/chunks/") {
+			t.Errorf("analyzed path %q should point into the bucket", path)
+		}
+	}
+
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV output: %v", err)
+	}
+	if len(rows) != len(chunks)+1 {
+		t.Fatalf("got %d rows, want header + %d records", len(rows), len(chunks))
+	}
+	if rows[1][5] != "A Song" {
+		t.Errorf("first record = %v", rows[1])
+	}
+}
+
+func TestInfoCmdRendersJSON(t *testing.T) {
+	info := &video.VideoInfo{
+		Path:       "clip.mp4",
+		Duration:   90 * time.Second,
+		Width:      1920,
+		Height:     1080,
+		FPS:        29.97,
+		VideoCodec: "h264",
+		AudioCodec: "aac",
+		BitRate:    5000000,
+		Size:       56250000,
+	}
+
+	cmd := &InfoCmd{JSON: true}
+	var buf bytes.Buffer
+	if err := cmd.render(info, &buf); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	for _, key := range []string{"path", "duration", "width", "height", "fps", "video_codec", "audio_codec", "bit_rate", "size"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("JSON output is missing %q:\n%s", key, buf.String())
+		}
+	}
+
+	cmd.JSON = false
+	buf.Reset()
+	if err := cmd.render(info, &buf); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if !strings.Contains(buf.String(), "1920x1080") || !strings.Contains(buf.String(), "h264") {
+		t.Errorf("text output missing fields:\n%s", buf.String())
+	}
+}
+
+func TestVersionString(t *testing.T) {
+	got := versionString()
+	if !strings.HasPrefix(got, "pipeline "+version) {
+		t.Errorf("versionString() = %q, want it to start with %q", got, "pipeline "+version)
+	}
+	if !strings.Contains(got, "revision") || !strings.Contains(got, "built") {
+		t.Errorf("versionString() = %q, want revision and build date", got)
+	}
+}
+
+func TestConfigureLogging(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	var buf bytes.Buffer
+	if err := configureLogging("warn", false, &buf); err != nil {
+		t.Fatalf("configureLogging: %v", err)
+	}
+	slog.Debug("debug detail")
+	slog.Warn("something happened")
+	if strings.Contains(buf.String(), "debug detail") {
+		t.Errorf("debug message emitted at warn level:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "something happened") {
+		t.Errorf("warn message missing:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := configureLogging("debug", true, &buf); err != nil {
+		t.Fatalf("configureLogging: %v", err)
+	}
+	slog.Debug("debug detail")
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("JSON log line is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if record["msg"] != "debug detail" {
+		t.Errorf("JSON log record = %v", record)
+	}
+
+	if err := configureLogging("noisy", false, &buf); err == nil {
+		t.Error("expected an error for an unknown log level")
+	}
+}
+
+func TestParseDelimiter(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    rune
+		wantErr bool
+	}{
+		{",", ',', false},
+		{"\\t", '\t', false},
+		{"tab", '\t', false},
+		{";", ';', false},
+		{"", 0, true},
+		{"ab", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDelimiter(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseDelimiter(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseDelimiter(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseMetadata(t *testing.T) {
+	metadata, err := parseMetadata([]string{"trip=roadtrip-2024", "note=a=b"})
+	if err != nil {
+		t.Fatalf("parseMetadata: %v", err)
+	}
+	if metadata["trip"] != "roadtrip-2024" || metadata["note"] != "a=b" {
+		t.Errorf("metadata = %v", metadata)
+	}
+
+	for _, bad := range []string{"no-separator", "=value"} {
+		if _, err := parseMetadata([]string{bad}); err == nil {
+			t.Errorf("parseMetadata(%q) succeeded, want error", bad)
+		}
+	}
+}
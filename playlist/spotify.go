@@ -0,0 +1,215 @@
+// Package playlist pushes analyzed songs out to playlist services.
+package playlist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"example.com/ai"
+)
+
+// SpotifyClient talks to the Spotify Web API. Searching uses an app token
+// obtained via the client-credentials flow; creating and filling playlists
+// requires a user-scoped OAuth token.
+type SpotifyClient struct {
+	clientID     string
+	clientSecret string
+	userToken    string
+
+	apiURL     string
+	authURL    string
+	httpClient *http.Client
+
+	// accessToken is the cached client-credentials token used for search.
+	accessToken string
+}
+
+// NewSpotifyClient creates a client with the given app credentials and
+// user token.
+func NewSpotifyClient(clientID, clientSecret, userToken string) *SpotifyClient {
+	return &SpotifyClient{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		userToken:    userToken,
+		apiURL:       "https://api.spotify.com/v1",
+		authURL:      "https://accounts.spotify.com/api/token",
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// SetBaseURLs points the client at alternative API and auth endpoints, which
+// tests use to substitute a local server.
+func (c *SpotifyClient) SetBaseURLs(apiURL, authURL string) {
+	c.apiURL = apiURL
+	c.authURL = authURL
+}
+
+// authenticate fetches and caches a client-credentials token for search.
+func (c *SpotifyClient) authenticate(ctx context.Context) error {
+	if c.accessToken != "" {
+		return nil
+	}
+	form := url.Values{"grant_type": {"client_credentials"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.authURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.clientID, c.clientSecret)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := c.do(req, &token); err != nil {
+		return fmt.Errorf("authenticating with Spotify: %w", err)
+	}
+	if token.AccessToken == "" {
+		return fmt.Errorf("Spotify token response had no access_token")
+	}
+	c.accessToken = token.AccessToken
+	return nil
+}
+
+// do sends the request and decodes a JSON response into out (when non-nil).
+func (c *SpotifyClient) do(req *http.Request, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, resp.Status, body)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// apiRequest builds an authorized API request with a JSON body.
+func (c *SpotifyClient) apiRequest(ctx context.Context, method, path, token string, body interface{}) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// SearchTrack returns the URI of the best match for the song, or "" when
+// Spotify has no match.
+func (c *SpotifyClient) SearchTrack(ctx context.Context, song ai.Song) (string, error) {
+	if err := c.authenticate(ctx); err != nil {
+		return "", err
+	}
+	query := "track:" + song.Title
+	if song.Artist != "" {
+		query += " artist:" + song.Artist
+	}
+	path := "/search?type=track&limit=1&q=" + url.QueryEscape(query)
+	req, err := c.apiRequest(ctx, http.MethodGet, path, c.accessToken, nil)
+	if err != nil {
+		return "", err
+	}
+	var result struct {
+		Tracks struct {
+			Items []struct {
+				URI string `json:"uri"`
+			} `json:"items"`
+		} `json:"tracks"`
+	}
+	if err := c.do(req, &result); err != nil {
+		return "", fmt.Errorf("searching for %q: %w", song.Title, err)
+	}
+	if len(result.Tracks.Items) == 0 {
+		return "", nil
+	}
+	return result.Tracks.Items[0].URI, nil
+}
+
+// CreatePlaylist creates a playlist for the token's user and returns its ID.
+func (c *SpotifyClient) CreatePlaylist(ctx context.Context, name string) (string, error) {
+	req, err := c.apiRequest(ctx, http.MethodGet, "/me", c.userToken, nil)
+	if err != nil {
+		return "", err
+	}
+	var user struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(req, &user); err != nil {
+		return "", fmt.Errorf("looking up the Spotify user: %w", err)
+	}
+
+	req, err = c.apiRequest(ctx, http.MethodPost, "/users/"+user.ID+"/playlists", c.userToken, map[string]string{"name": name})
+	if err != nil {
+		return "", err
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := c.do(req, &created); err != nil {
+		return "", fmt.Errorf("creating playlist %q: %w", name, err)
+	}
+	return created.ID, nil
+}
+
+// AddTracks appends the track URIs to the playlist.
+func (c *SpotifyClient) AddTracks(ctx context.Context, playlistID string, uris []string) error {
+	req, err := c.apiRequest(ctx, http.MethodPost, "/playlists/"+playlistID+"/tracks", c.userToken, map[string][]string{"uris": uris})
+	if err != nil {
+		return err
+	}
+	if err := c.do(req, nil); err != nil {
+		return fmt.Errorf("adding tracks to playlist %s: %w", playlistID, err)
+	}
+	return nil
+}
+
+// BuildPlaylist creates the named playlist, searches for each song, and adds
+// every match. It returns the number of tracks added; songs without a match
+// are logged and skipped.
+func (c *SpotifyClient) BuildPlaylist(ctx context.Context, name string, songs []ai.Song) (int, error) {
+	var uris []string
+	for _, song := range songs {
+		uri, err := c.SearchTrack(ctx, song)
+		if err != nil {
+			return 0, err
+		}
+		if uri == "" {
+			slog.Warn("no Spotify match", "title", song.Title, "artist", song.Artist)
+			continue
+		}
+		uris = append(uris, uri)
+	}
+	if len(uris) == 0 {
+		return 0, nil
+	}
+	playlistID, err := c.CreatePlaylist(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if err := c.AddTracks(ctx, playlistID, uris); err != nil {
+		return 0, err
+	}
+	return len(uris), nil
+}
@@ -0,0 +1,127 @@
+package playlist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"example.com/ai"
+)
+
+// newTestSpotify starts a fake Spotify API and returns a client pointed at it
+// along with the recorded request paths.
+func newTestSpotify(t *testing.T) (*SpotifyClient, *[]string) {
+	t.Helper()
+	var calls []string
+
+	auth := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "client-id" || pass != "client-secret" {
+			t.Errorf("token request credentials = %q/%q", user, pass)
+		}
+		calls = append(calls, "token")
+		fmt.Fprint(w, `{"access_token": "app-token"}`)
+	}))
+	t.Cleanup(auth.Close)
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.Method+" "+r.URL.Path)
+		switch {
+		case r.URL.Path == "/search":
+			if got := r.Header.Get("Authorization"); got != "Bearer app-token" {
+				t.Errorf("search Authorization = %q", got)
+			}
+			query := r.URL.Query().Get("q")
+			if query == "track:A Song artist:An Artist" {
+				fmt.Fprint(w, `{"tracks": {"items": [{"uri": "spotify:track:abc123"}]}}`)
+				return
+			}
+			fmt.Fprint(w, `{"tracks": {"items": []}}`)
+		case r.URL.Path == "/me":
+			if got := r.Header.Get("Authorization"); got != "Bearer user-token" {
+				t.Errorf("/me Authorization = %q", got)
+			}
+			fmt.Fprint(w, `{"id": "user1"}`)
+		case r.URL.Path == "/users/user1/playlists":
+			var body struct {
+				Name string `json:"name"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Name != "Roadtrip" {
+				t.Errorf("playlist body = %+v, err = %v", body, err)
+			}
+			fmt.Fprint(w, `{"id": "pl1"}`)
+		case r.URL.Path == "/playlists/pl1/tracks":
+			var body struct {
+				URIs []string `json:"uris"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decoding tracks body: %v", err)
+			}
+			if len(body.URIs) != 1 || body.URIs[0] != "spotify:track:abc123" {
+				t.Errorf("added URIs = %v", body.URIs)
+			}
+			fmt.Fprint(w, `{"snapshot_id": "snap"}`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(api.Close)
+
+	client := NewSpotifyClient("client-id", "client-secret", "user-token")
+	client.SetBaseURLs(api.URL, auth.URL)
+	return client, &calls
+}
+
+func TestSpotifyClientBuildPlaylist(t *testing.T) {
+	client, calls := newTestSpotify(t)
+
+	songs := []ai.Song{
+		{Title: "A Song", Artist: "An Artist"},
+		{Title: "Unknown Tune", Artist: "Nobody"},
+	}
+	added, err := client.BuildPlaylist(context.Background(), "Roadtrip", songs)
+	if err != nil {
+		t.Fatalf("BuildPlaylist: %v", err)
+	}
+	if added != 1 {
+		t.Errorf("added = %d, want 1 (the unmatched song is skipped)", added)
+	}
+
+	want := []string{
+		"token",
+		"GET /search",
+		"GET /search",
+		"GET /me",
+		"POST /users/user1/playlists",
+		"POST /playlists/pl1/tracks",
+	}
+	if len(*calls) != len(want) {
+		t.Fatalf("calls = %v, want %v", *calls, want)
+	}
+	for i, call := range want {
+		if (*calls)[i] != call {
+			t.Errorf("call %d = %q, want %q", i, (*calls)[i], call)
+		}
+	}
+}
+
+func TestSpotifyClientBuildPlaylistNoMatches(t *testing.T) {
+	client, calls := newTestSpotify(t)
+
+	added, err := client.BuildPlaylist(context.Background(), "Roadtrip", []ai.Song{{Title: "Unknown Tune"}})
+	if err != nil {
+		t.Fatalf("BuildPlaylist: %v", err)
+	}
+	if added != 0 {
+		t.Errorf("added = %d, want 0", added)
+	}
+	for _, call := range *calls {
+		if call == "GET /me" {
+			t.Error("no playlist should be created when nothing matched")
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package progress
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultETAWindow is how many recent items the rolling average covers when
+// the caller does not choose a window.
+const defaultETAWindow = 10
+
+// ETA estimates the time remaining for a batch from a rolling average of
+// per-item durations, so a few slow outliers early on don't dominate the
+// estimate. It is safe for concurrent use by worker pools.
+type ETA struct {
+	mu        sync.Mutex
+	total     int
+	done      int
+	durations []time.Duration // ring buffer of the most recent items
+	next      int
+}
+
+// NewETA creates an estimator for a batch of total items averaging over up
+// to window recent items; window <= 0 selects a sensible default.
+func NewETA(total, window int) *ETA {
+	if window <= 0 {
+		window = defaultETAWindow
+	}
+	return &ETA{total: total, durations: make([]time.Duration, 0, window)}
+}
+
+// Record notes one finished item taking d and returns the estimated time
+// remaining for the rest of the batch: the rolling average multiplied by the
+// number of items left, or zero once every item is done.
+func (e *ETA) Record(d time.Duration) time.Duration {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.durations) < cap(e.durations) {
+		e.durations = append(e.durations, d)
+	} else {
+		e.durations[e.next] = d
+		e.next = (e.next + 1) % len(e.durations)
+	}
+	e.done++
+	left := e.total - e.done
+	if left <= 0 {
+		return 0
+	}
+	var sum time.Duration
+	for _, d := range e.durations {
+		sum += d
+	}
+	avg := sum / time.Duration(len(e.durations))
+	return avg * time.Duration(left)
+}
@@ -0,0 +1,37 @@
+package progress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestETARollingAverage(t *testing.T) {
+	eta := NewETA(10, 3)
+
+	if got := eta.Record(2 * time.Second); got != 18*time.Second {
+		t.Errorf("after one 2s item, ETA = %v, want 18s", got)
+	}
+	eta.Record(4 * time.Second)
+
+	// Midpoint: three of ten done, window holds 2s, 4s, 6s.
+	if got := eta.Record(6 * time.Second); got != 28*time.Second {
+		t.Errorf("midpoint ETA = %v, want 4s average over 7 remaining = 28s", got)
+	}
+
+	// Two more 6s items push the 2s and 4s items out of the window.
+	eta.Record(6 * time.Second)
+	if got := eta.Record(6 * time.Second); got != 30*time.Second {
+		t.Errorf("ETA = %v, want 6s average over 5 remaining = 30s", got)
+	}
+}
+
+func TestETAZeroWhenBatchDone(t *testing.T) {
+	eta := NewETA(2, 0)
+	eta.Record(time.Second)
+	if got := eta.Record(time.Second); got != 0 {
+		t.Errorf("ETA after the last item = %v, want 0", got)
+	}
+	if got := eta.Record(time.Second); got != 0 {
+		t.Errorf("ETA past the end of the batch = %v, want 0", got)
+	}
+}
@@ -0,0 +1,72 @@
+// Package progress renders a single-line terminal progress bar for batch
+// operations, staying silent when the output is not a terminal.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Bar is a single-line progress bar showing steps done out of a total plus
+// the item currently being worked on. A Bar over a non-terminal writer
+// renders nothing, so callers can drive it unconditionally and keep their
+// log lines as the fallback.
+type Bar struct {
+	mu      sync.Mutex
+	w       io.Writer
+	total   int
+	done    int
+	enabled bool
+}
+
+// NewBar creates a bar over total steps writing to w, typically os.Stdout.
+// The bar renders only when w is a terminal.
+func NewBar(w io.Writer, total int) *Bar {
+	return &Bar{w: w, total: total, enabled: isTerminal(w)}
+}
+
+// Enabled reports whether the bar will render. It is safe to call on a nil
+// bar, so call sites can fall back to their log lines with one check.
+func (b *Bar) Enabled() bool {
+	return b != nil && b.enabled
+}
+
+// Step marks one step done and redraws the bar with label (reduced to its
+// basename) as the current item. It is a no-op on a nil or disabled bar.
+func (b *Bar) Step(label string) {
+	if !b.Enabled() {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done++
+	fmt.Fprintf(b.w, "\r\033[K[%d/%d] %s", b.done, b.total, filepath.Base(label))
+}
+
+// Finish terminates the bar's line so subsequent output starts cleanly. It
+// is a no-op on a nil or disabled bar.
+func (b *Bar) Finish() {
+	if !b.Enabled() {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	fmt.Fprintln(b.w)
+}
+
+// isTerminal reports whether w is a character device, i.e. an interactive
+// terminal rather than a pipe or file.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,49 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBarSilentOnNonTerminalWriter(t *testing.T) {
+	var buf bytes.Buffer
+	bar := NewBar(&buf, 3)
+	if bar.Enabled() {
+		t.Error("bar over a bytes.Buffer should not be enabled")
+	}
+	bar.Step("chunk_001.mp4")
+	bar.Step("chunk_002.mp4")
+	bar.Finish()
+	if buf.Len() != 0 {
+		t.Errorf("disabled bar wrote output: %q", buf.String())
+	}
+}
+
+func TestBarNilIsSafe(t *testing.T) {
+	var bar *Bar
+	if bar.Enabled() {
+		t.Error("nil bar should report disabled")
+	}
+	bar.Step("chunk_001.mp4")
+	bar.Finish()
+}
+
+func TestBarRendersStepsWhenEnabled(t *testing.T) {
+	var buf bytes.Buffer
+	bar := &Bar{w: &buf, total: 2, enabled: true}
+	bar.Step("out/chunk_001.mp4")
+	bar.Step("out/chunk_002.mp4")
+	bar.Finish()
+
+	out := buf.String()
+	if !strings.Contains(out, "[1/2] chunk_001.mp4") {
+		t.Errorf("output %q missing the first step", out)
+	}
+	if !strings.Contains(out, "[2/2] chunk_002.mp4") {
+		t.Errorf("output %q missing the second step", out)
+	}
+	if !strings.HasSuffix(out, "\n") {
+		t.Errorf("output %q should end with a newline after Finish", out)
+	}
+}
@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	gcs "cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// GCSClient uploads files to a Google Cloud Storage bucket.
+type GCSClient struct {
+	client          *gcs.Client
+	projectID       string
+	bucketName      string
+	verifyChecksums bool
+	progress        ProgressFunc
+	metadata        map[string]string
+}
+
+// NewGCSClient creates a client for the given project and bucket using
+// application default credentials.
+func NewGCSClient(ctx context.Context, projectID, bucketName string) (*GCSClient, error) {
+	return NewGCSClientWithCredentialsFile(ctx, projectID, bucketName, "")
+}
+
+// NewGCSClientWithCredentialsFile creates a client authenticating with the
+// given service-account JSON file. An empty path falls back to application
+// default credentials.
+func NewGCSClientWithCredentialsFile(ctx context.Context, projectID, bucketName, credentialsFile string) (*GCSClient, error) {
+	return newGCSClient(ctx, projectID, bucketName, gcsClientOptions(credentialsFile, "", false)...)
+}
+
+// NewGCSClientWithEndpoint creates a client that talks to endpoint instead of
+// the production API, e.g. a fake-gcs-server emulator. Setting disableAuth
+// skips credential lookup entirely, which emulators neither need nor verify.
+func NewGCSClientWithEndpoint(ctx context.Context, projectID, bucketName, endpoint string, disableAuth bool) (*GCSClient, error) {
+	return newGCSClient(ctx, projectID, bucketName, gcsClientOptions("", endpoint, disableAuth)...)
+}
+
+// gcsClientOptions assembles the client options for the given overrides.
+func gcsClientOptions(credentialsFile, endpoint string, disableAuth bool) []option.ClientOption {
+	var opts []option.ClientOption
+	if credentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(credentialsFile))
+	}
+	if endpoint != "" {
+		opts = append(opts, option.WithEndpoint(endpoint))
+	}
+	if disableAuth {
+		opts = append(opts, option.WithoutAuthentication())
+	}
+	return opts
+}
+
+func newGCSClient(ctx context.Context, projectID, bucketName string, opts ...option.ClientOption) (*GCSClient, error) {
+	client, err := gcs.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("creating GCS client: %w", err)
+	}
+	return &GCSClient{
+		client:     client,
+		projectID:  projectID,
+		bucketName: bucketName,
+	}, nil
+}
+
+// UploadFile copies the local file to gs://<bucket>/<remotePath>.
+func (c *GCSClient) UploadFile(ctx context.Context, localPath, remotePath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", localPath, err)
+	}
+	defer f.Close()
+
+	writer := c.client.Bucket(c.bucketName).Object(remotePath).NewWriter(ctx)
+	writer.ContentType = contentTypeForFile(localPath)
+	writer.Metadata = objectMetadata(c.metadata)
+	if c.verifyChecksums {
+		md5Sum, crc, err := fileChecksums(localPath)
+		if err != nil {
+			return fmt.Errorf("checksumming %s: %w", localPath, err)
+		}
+		// GCS rejects the finalizing request if the received bytes do not
+		// match these checksums.
+		writer.MD5 = md5Sum
+		writer.CRC32C = crc
+		writer.SendCRC32C = true
+	}
+	var reader io.Reader = f
+	if c.progress != nil {
+		info, err := f.Stat()
+		if err != nil {
+			return fmt.Errorf("stating %s: %w", localPath, err)
+		}
+		reader = &progressReader{r: f, file: localPath, total: info.Size(), fn: c.progress}
+	}
+	if _, err := io.Copy(writer, reader); err != nil {
+		writer.Close()
+		return fmt.Errorf("writing %s: %w", remotePath, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("finalizing %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// DownloadFile copies gs://<bucket>/<remotePath> to the local path, creating
+// parent directories as needed.
+func (c *GCSClient) DownloadFile(ctx context.Context, remotePath, localPath string) error {
+	reader, err := c.client.Bucket(c.bucketName).Object(remotePath).NewReader(ctx)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", remotePath, err)
+	}
+	defer reader.Close()
+	return writeLocalFile(localPath, reader)
+}
+
+// ListObjects returns the names of all objects in the bucket under prefix.
+func (c *GCSClient) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	it := c.client.Bucket(c.bucketName).Objects(ctx, &gcs.Query{Prefix: prefix})
+	var names []string
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("listing %s: %w", prefix, err)
+		}
+		names = append(names, attrs.Name)
+	}
+	return names, nil
+}
+
+// StatObject describes the object at remotePath, or returns (nil, nil) if it
+// does not exist.
+func (c *GCSClient) StatObject(ctx context.Context, remotePath string) (*ObjectInfo, error) {
+	attrs, err := c.client.Bucket(c.bucketName).Object(remotePath).Attrs(ctx)
+	if err == gcs.ErrObjectNotExist {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stating %s: %w", remotePath, err)
+	}
+	return &ObjectInfo{Name: attrs.Name, Size: attrs.Size}, nil
+}
+
+// SignedURL mints a V4-signed GET URL for the object that stays valid for
+// ttl.
+func (c *GCSClient) SignedURL(_ context.Context, remotePath string, ttl time.Duration) (string, error) {
+	if err := validateTTL(ttl); err != nil {
+		return "", err
+	}
+	url, err := c.client.Bucket(c.bucketName).SignedURL(remotePath, &gcs.SignedURLOptions{
+		Method:  http.MethodGet,
+		Expires: time.Now().Add(ttl),
+		Scheme:  gcs.SigningSchemeV4,
+	})
+	if err != nil {
+		return "", fmt.Errorf("signing URL for %s: %w", remotePath, err)
+	}
+	return url, nil
+}
+
+// SetVerifyChecksums implements ChecksumVerifier.
+func (c *GCSClient) SetVerifyChecksums(verify bool) {
+	c.verifyChecksums = verify
+}
+
+// SetProgressFunc implements ProgressReporter.
+func (c *GCSClient) SetProgressFunc(fn ProgressFunc) {
+	c.progress = fn
+}
+
+// SetObjectMetadata implements MetadataSetter.
+func (c *GCSClient) SetObjectMetadata(metadata map[string]string) {
+	c.metadata = metadata
+}
+
+// UploadReader implements ReaderUploader by streaming r into the object at
+// remotePath. The size hint is unused; GCS sizes the object from the stream.
+func (c *GCSClient) UploadReader(ctx context.Context, r io.Reader, _ int64, remotePath string) error {
+	writer := c.client.Bucket(c.bucketName).Object(remotePath).NewWriter(ctx)
+	writer.ContentType = contentTypeForFile(remotePath)
+	writer.Metadata = objectMetadata(c.metadata)
+	if _, err := io.Copy(writer, r); err != nil {
+		writer.Close()
+		return fmt.Errorf("writing %s: %w", remotePath, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("finalizing %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// OpenObject streams the object's content.
+func (c *GCSClient) OpenObject(ctx context.Context, remotePath string) (io.ReadCloser, error) {
+	reader, err := c.client.Bucket(c.bucketName).Object(remotePath).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", remotePath, err)
+	}
+	return reader, nil
+}
+
+// Close releases the underlying GCS client.
+func (c *GCSClient) Close() error {
+	return c.client.Close()
+}
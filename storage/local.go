@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// localMetadataSuffix marks the sidecar file written next to each object.
+const localMetadataSuffix = ".metadata.json"
+
+// LocalStorageClient implements StorageClient against a local directory so
+// the pipeline can run without any cloud account.
+type LocalStorageClient struct {
+	baseDir string
+}
+
+// NewLocalStorageClient stores objects under baseDir, creating it if needed.
+func NewLocalStorageClient(baseDir string) (*LocalStorageClient, error) {
+	if baseDir == "" {
+		return nil, errors.New("local storage directory must not be empty")
+	}
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating %s: %w", baseDir, err)
+	}
+	return &LocalStorageClient{baseDir: baseDir}, nil
+}
+
+// objectPath maps a remote path to its location under the base directory.
+func (c *LocalStorageClient) objectPath(remotePath string) string {
+	return filepath.Join(c.baseDir, filepath.FromSlash(remotePath))
+}
+
+// UploadFile copies the local file under the base directory, preserving the
+// remote path structure, and writes a metadata sidecar next to it.
+func (c *LocalStorageClient) UploadFile(_ context.Context, localPath, remotePath string) error {
+	src, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", localPath, err)
+	}
+	defer src.Close()
+
+	dst := c.objectPath(remotePath)
+	if err := writeLocalFile(dst, src); err != nil {
+		return err
+	}
+	meta, err := json.MarshalIndent(map[string]string{
+		"uploaded-by":  uploadedByMetadata,
+		"upload-time":  time.Now().UTC().Format(time.RFC3339),
+		"content-type": contentTypeForFile(localPath),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst+localMetadataSuffix, meta, 0o644)
+}
+
+// UploadReader implements ReaderUploader by streaming r into the object at
+// remotePath, with the same metadata sidecar UploadFile writes.
+func (c *LocalStorageClient) UploadReader(_ context.Context, r io.Reader, _ int64, remotePath string) error {
+	dst := c.objectPath(remotePath)
+	if err := writeLocalFile(dst, r); err != nil {
+		return err
+	}
+	meta, err := json.MarshalIndent(map[string]string{
+		"uploaded-by":  uploadedByMetadata,
+		"upload-time":  time.Now().UTC().Format(time.RFC3339),
+		"content-type": contentTypeForFile(remotePath),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst+localMetadataSuffix, meta, 0o644)
+}
+
+// OpenObject streams the object's content.
+func (c *LocalStorageClient) OpenObject(_ context.Context, remotePath string) (io.ReadCloser, error) {
+	src, err := os.Open(c.objectPath(remotePath))
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", remotePath, err)
+	}
+	return src, nil
+}
+
+// DownloadFile copies the object at remotePath to the local path, creating
+// parent directories as needed.
+func (c *LocalStorageClient) DownloadFile(_ context.Context, remotePath, localPath string) error {
+	src, err := os.Open(c.objectPath(remotePath))
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", remotePath, err)
+	}
+	defer src.Close()
+	return writeLocalFile(localPath, src)
+}
+
+// ListObjects walks the base directory and returns the objects under prefix,
+// skipping metadata sidecars.
+func (c *LocalStorageClient) ListObjects(_ context.Context, prefix string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(c.baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(path, localMetadataSuffix) {
+			return nil
+		}
+		rel, err := filepath.Rel(c.baseDir, path)
+		if err != nil {
+			return err
+		}
+		if name := filepath.ToSlash(rel); strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %w", prefix, err)
+	}
+	return names, nil
+}
+
+// StatObject describes the object at remotePath, or returns (nil, nil) if it
+// does not exist.
+func (c *LocalStorageClient) StatObject(_ context.Context, remotePath string) (*ObjectInfo, error) {
+	stat, err := os.Stat(c.objectPath(remotePath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stating %s: %w", remotePath, err)
+	}
+	return &ObjectInfo{Name: remotePath, Size: stat.Size()}, nil
+}
+
+// Close implements StorageClient. There is nothing to release.
+func (c *LocalStorageClient) Close() error {
+	return nil
+}
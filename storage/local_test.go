@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var _ StorageClient = (*LocalStorageClient)(nil)
+
+func TestLocalStorageClientUploadFile(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "chunk_001.mp4")
+	if err := os.WriteFile(src, []byte("chunk data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	baseDir := t.TempDir()
+	client, err := NewLocalStorageClient(baseDir)
+	if err != nil {
+		t.Fatalf("NewLocalStorageClient: %v", err)
+	}
+	defer client.Close()
+
+	if err := client.UploadFile(context.Background(), src, "trip/chunks/chunk_001.mp4"); err != nil {
+		t.Fatalf("UploadFile: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(baseDir, "trip", "chunks", "chunk_001.mp4"))
+	if err != nil {
+		t.Fatalf("reading stored object: %v", err)
+	}
+	if string(data) != "chunk data" {
+		t.Errorf("stored object content = %q", data)
+	}
+	meta, err := os.ReadFile(filepath.Join(baseDir, "trip", "chunks", "chunk_001.mp4"+localMetadataSuffix))
+	if err != nil {
+		t.Fatalf("reading metadata sidecar: %v", err)
+	}
+	if !strings.Contains(string(meta), "video/mp4") {
+		t.Errorf("metadata sidecar %q should record the content type", meta)
+	}
+}
+
+func TestLocalStorageClientListAndStat(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "chunk_001.mp4")
+	if err := os.WriteFile(src, []byte("chunk data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewLocalStorageClient(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorageClient: %v", err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	for _, remote := range []string{"chunks/chunk_001.mp4", "other/chunk_001.mp4"} {
+		if err := client.UploadFile(ctx, src, remote); err != nil {
+			t.Fatalf("UploadFile(%s): %v", remote, err)
+		}
+	}
+
+	names, err := client.ListObjects(ctx, "chunks/")
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	if len(names) != 1 || names[0] != "chunks/chunk_001.mp4" {
+		t.Errorf("ListObjects = %v, want [chunks/chunk_001.mp4]", names)
+	}
+
+	info, err := client.StatObject(ctx, "chunks/chunk_001.mp4")
+	if err != nil {
+		t.Fatalf("StatObject: %v", err)
+	}
+	if info == nil || info.Size != int64(len("chunk data")) {
+		t.Errorf("StatObject = %+v, want size %d", info, len("chunk data"))
+	}
+	missing, err := client.StatObject(ctx, "chunks/chunk_999.mp4")
+	if err != nil || missing != nil {
+		t.Errorf("StatObject for missing object = (%+v, %v), want (nil, nil)", missing, err)
+	}
+}
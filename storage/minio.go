@@ -0,0 +1,181 @@
+package storage
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// MinIOClient uploads files to a MinIO (or other S3-compatible) endpoint.
+type MinIOClient struct {
+	client          *minio.Client
+	transport       *http.Transport
+	bucketName      string
+	verifyChecksums bool
+	progress        ProgressFunc
+	metadata        map[string]string
+}
+
+// NewMinIOClient creates a client for the given endpoint and bucket.
+func NewMinIOClient(endpoint, accessKey, secretKey, bucketName string, useSSL bool) (*MinIOClient, error) {
+	// Own the transport so Close can drop its idle connections.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:     credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure:    useSSL,
+		Transport: transport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating MinIO client: %w", err)
+	}
+	return &MinIOClient{client: client, transport: transport, bucketName: bucketName}, nil
+}
+
+// UploadFile copies the local file to the bucket at remotePath.
+func (c *MinIOClient) UploadFile(ctx context.Context, localPath, remotePath string) error {
+	opts := minio.PutObjectOptions{
+		ContentType:  contentTypeForFile(localPath),
+		UserMetadata: objectMetadata(c.metadata),
+	}
+	var wantMD5 string
+	if c.verifyChecksums {
+		md5Sum, _, err := fileChecksums(localPath)
+		if err != nil {
+			return fmt.Errorf("checksumming %s: %w", localPath, err)
+		}
+		opts.SendContentMd5 = true
+		wantMD5 = hex.EncodeToString(md5Sum)
+	}
+	f, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", localPath, err)
+	}
+	defer f.Close()
+	stat, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stating %s: %w", localPath, err)
+	}
+	var reader io.Reader = f
+	if c.progress != nil {
+		reader = &progressReader{r: f, file: localPath, total: stat.Size(), fn: c.progress}
+	}
+	info, err := c.client.PutObject(ctx, c.bucketName, remotePath, reader, stat.Size(), opts)
+	if err != nil {
+		return fmt.Errorf("uploading %s: %w", remotePath, err)
+	}
+	// The ETag equals the MD5 for single-part uploads; multipart ETags
+	// (containing a dash) cannot be compared this way.
+	if wantMD5 != "" && !strings.Contains(info.ETag, "-") && info.ETag != wantMD5 {
+		return fmt.Errorf("checksum mismatch for %s: remote %s, local %s", localPath, info.ETag, wantMD5)
+	}
+	return nil
+}
+
+// DownloadFile copies the object at remotePath to the local path, creating
+// parent directories as needed.
+func (c *MinIOClient) DownloadFile(ctx context.Context, remotePath, localPath string) error {
+	obj, err := c.client.GetObject(ctx, c.bucketName, remotePath, minio.GetObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", remotePath, err)
+	}
+	defer obj.Close()
+	return writeLocalFile(localPath, obj)
+}
+
+// ListObjects returns the names of all objects in the bucket under prefix.
+func (c *MinIOClient) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	for obj := range c.client.ListObjects(ctx, c.bucketName, minio.ListObjectsOptions{
+		Prefix:    prefix,
+		Recursive: true,
+	}) {
+		if obj.Err != nil {
+			return nil, fmt.Errorf("listing %s: %w", prefix, obj.Err)
+		}
+		names = append(names, obj.Key)
+	}
+	return names, nil
+}
+
+// StatObject describes the object at remotePath, or returns (nil, nil) if it
+// does not exist.
+func (c *MinIOClient) StatObject(ctx context.Context, remotePath string) (*ObjectInfo, error) {
+	info, err := c.client.StatObject(ctx, c.bucketName, remotePath, minio.StatObjectOptions{})
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stating %s: %w", remotePath, err)
+	}
+	return &ObjectInfo{Name: info.Key, Size: info.Size}, nil
+}
+
+// SignedURL mints a presigned GET URL for the object that stays valid for
+// ttl.
+func (c *MinIOClient) SignedURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error) {
+	if err := validateTTL(ttl); err != nil {
+		return "", err
+	}
+	url, err := c.client.PresignedGetObject(ctx, c.bucketName, remotePath, ttl, nil)
+	if err != nil {
+		return "", fmt.Errorf("signing URL for %s: %w", remotePath, err)
+	}
+	return url.String(), nil
+}
+
+// SetVerifyChecksums implements ChecksumVerifier.
+func (c *MinIOClient) SetVerifyChecksums(verify bool) {
+	c.verifyChecksums = verify
+}
+
+// SetObjectMetadata implements MetadataSetter.
+func (c *MinIOClient) SetObjectMetadata(metadata map[string]string) {
+	c.metadata = metadata
+}
+
+// UploadReader implements ReaderUploader by streaming r into the object at
+// remotePath. Pass size -1 when the stream length is unknown; MinIO then
+// falls back to a multipart upload.
+func (c *MinIOClient) UploadReader(ctx context.Context, r io.Reader, size int64, remotePath string) error {
+	opts := minio.PutObjectOptions{
+		ContentType:  contentTypeForFile(remotePath),
+		UserMetadata: objectMetadata(c.metadata),
+	}
+	if _, err := c.client.PutObject(ctx, c.bucketName, remotePath, r, size, opts); err != nil {
+		return fmt.Errorf("uploading %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// SetProgressFunc implements ProgressReporter.
+func (c *MinIOClient) SetProgressFunc(fn ProgressFunc) {
+	c.progress = fn
+}
+
+// OpenObject streams the object's content.
+func (c *MinIOClient) OpenObject(ctx context.Context, remotePath string) (io.ReadCloser, error) {
+	obj, err := c.client.GetObject(ctx, c.bucketName, remotePath, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", remotePath, err)
+	}
+	return obj, nil
+}
+
+// Close drops the transport's idle connections and marks the client
+// unusable. It is safe to call more than once.
+func (c *MinIOClient) Close() error {
+	if c.transport != nil {
+		c.transport.CloseIdleConnections()
+		c.transport = nil
+	}
+	c.client = nil
+	return nil
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Client uploads files to an AWS S3 bucket.
+type S3Client struct {
+	client     *s3.Client
+	bucketName string
+}
+
+// NewS3Client creates a client for the given region and bucket using the
+// standard AWS credential chain (env vars, shared config, instance roles).
+func NewS3Client(ctx context.Context, region, bucketName string) (*S3Client, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+	return &S3Client{
+		client:     s3.NewFromConfig(cfg),
+		bucketName: bucketName,
+	}, nil
+}
+
+// UploadFile copies the local file to s3://<bucket>/<remotePath>.
+func (c *S3Client) UploadFile(ctx context.Context, localPath, remotePath string) error {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", localPath, err)
+	}
+	defer f.Close()
+
+	_, err = c.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(c.bucketName),
+		Key:    aws.String(remotePath),
+		Body:   f,
+		Metadata: map[string]string{
+			"uploaded-by": uploadedByMetadata,
+			"upload-time": time.Now().UTC().Format(time.RFC3339),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("uploading %s: %w", remotePath, err)
+	}
+	return nil
+}
+
+// DownloadFile copies s3://<bucket>/<remotePath> to the local path, creating
+// parent directories as needed.
+func (c *S3Client) DownloadFile(ctx context.Context, remotePath, localPath string) error {
+	out, err := c.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucketName),
+		Key:    aws.String(remotePath),
+	})
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", remotePath, err)
+	}
+	defer out.Body.Close()
+	return writeLocalFile(localPath, out.Body)
+}
+
+// ListObjects returns the keys of all objects in the bucket under prefix.
+func (c *S3Client) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	var names []string
+	paginator := s3.NewListObjectsV2Paginator(c.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucketName),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing %s: %w", prefix, err)
+		}
+		for _, obj := range page.Contents {
+			names = append(names, aws.ToString(obj.Key))
+		}
+	}
+	return names, nil
+}
+
+// StatObject describes the object at remotePath, or returns (nil, nil) if it
+// does not exist.
+func (c *S3Client) StatObject(ctx context.Context, remotePath string) (*ObjectInfo, error) {
+	out, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.bucketName),
+		Key:    aws.String(remotePath),
+	})
+	var notFound *types.NotFound
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stating %s: %w", remotePath, err)
+	}
+	return &ObjectInfo{Name: remotePath, Size: aws.ToInt64(out.ContentLength)}, nil
+}
+
+// Close implements StorageClient. The S3 SDK does not require explicit
+// cleanup.
+func (c *S3Client) Close() error {
+	return nil
+}
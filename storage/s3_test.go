@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"context"
+	"testing"
+)
+
+var _ StorageClient = (*S3Client)(nil)
+
+func TestNewS3Client(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping S3 client creation in short mode")
+	}
+	client, err := NewS3Client(context.Background(), "us-east-1", "test-bucket")
+	if err != nil {
+		// No AWS config in most test environments; that's fine.
+		t.Skipf("NewS3Client: %v", err)
+	}
+	client.Close()
+}
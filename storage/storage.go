@@ -0,0 +1,858 @@
+// Package storage uploads chunk files to cloud object stores (GCS or a
+// MinIO-compatible endpoint) for later analysis.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"example.com/progress"
+)
+
+// uploadedByMetadata tags every uploaded object with its origin.
+const uploadedByMetadata = "go-bazel-devcontainer-demo"
+
+// StorageClient abstracts the object store the pipeline uploads to, so the
+// upload flow can be tested with MockStorageClient.
+type StorageClient interface {
+	UploadFile(ctx context.Context, localPath, remotePath string) error
+	DownloadFile(ctx context.Context, remotePath, localPath string) error
+	ListObjects(ctx context.Context, prefix string) ([]string, error)
+	// StatObject describes the remote object, or returns (nil, nil) if it
+	// does not exist.
+	StatObject(ctx context.Context, remotePath string) (*ObjectInfo, error)
+	Close() error
+}
+
+// ObjectInfo describes a remote object returned by StatObject.
+type ObjectInfo struct {
+	Name string
+	Size int64
+}
+
+// writeLocalFile streams r to localPath, creating parent directories and
+// writing through a temp file so readers never observe a partial download.
+func writeLocalFile(localPath string, r io.Reader) error {
+	dir := filepath.Dir(localPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("creating %s: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(localPath)+".download-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing %s: %w", localPath, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), localPath)
+}
+
+// videoContentTypes covers media extensions the platform mime database often
+// lacks or reports inconsistently.
+var videoContentTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".mov":  "video/quicktime",
+	".mkv":  "video/x-matroska",
+	".webm": "video/webm",
+	".m4a":  "audio/mp4",
+	".wav":  "audio/wav",
+}
+
+// contentTypeForFile guesses the MIME type of a file from its extension,
+// falling back to application/octet-stream for unknown extensions.
+func contentTypeForFile(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ct, ok := videoContentTypes[ext]; ok {
+		return ct
+	}
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
+
+// URLSigner is implemented by storage clients that can mint time-limited
+// download URLs without making the bucket public.
+type URLSigner interface {
+	SignedURL(ctx context.Context, remotePath string, ttl time.Duration) (string, error)
+}
+
+// validateTTL rejects zero and negative signed-URL lifetimes.
+func validateTTL(ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("ttl must be positive, got %v", ttl)
+	}
+	return nil
+}
+
+// ChecksumVerifier is implemented by storage clients that can verify uploads
+// against locally computed checksums.
+type ChecksumVerifier interface {
+	SetVerifyChecksums(verify bool)
+}
+
+// MetadataSetter is implemented by storage clients that can attach custom
+// key/value metadata to uploaded objects.
+type MetadataSetter interface {
+	SetObjectMetadata(metadata map[string]string)
+}
+
+// ReaderUploader is implemented by storage clients that can upload straight
+// from a stream without the content touching disk. size is the number of
+// bytes r will yield, or -1 when unknown.
+type ReaderUploader interface {
+	UploadReader(ctx context.Context, r io.Reader, size int64, remotePath string) error
+}
+
+// ObjectOpener is implemented by storage clients that can stream an
+// object's content, so remote files can be read without staging them to
+// disk. The caller closes the returned reader.
+type ObjectOpener interface {
+	OpenObject(ctx context.Context, remotePath string) (io.ReadCloser, error)
+}
+
+// objectMetadata merges custom metadata with the standard upload tags. The
+// standard keys always win so provenance cannot be overridden.
+func objectMetadata(custom map[string]string) map[string]string {
+	md := make(map[string]string, len(custom)+2)
+	for key, value := range custom {
+		md[key] = value
+	}
+	md["uploaded-by"] = uploadedByMetadata
+	md["upload-time"] = time.Now().UTC().Format(time.RFC3339)
+	return md
+}
+
+// ProgressFunc receives byte-level progress while a file uploads.
+type ProgressFunc func(file string, bytesDone, bytesTotal int64)
+
+// ProgressReporter is implemented by storage clients that can report
+// byte-level upload progress.
+type ProgressReporter interface {
+	SetProgressFunc(fn ProgressFunc)
+}
+
+// progressReader wraps a reader and reports cumulative bytes read to a
+// ProgressFunc.
+type progressReader struct {
+	r     io.Reader
+	file  string
+	done  int64
+	total int64
+	fn    ProgressFunc
+}
+
+func (p *progressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	if n > 0 {
+		p.done += int64(n)
+		p.fn(p.file, p.done, p.total)
+	}
+	return n, err
+}
+
+// fileSHA256 computes the hex SHA-256 digest of a local file.
+func fileSHA256(localPath string) (string, error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fileChecksums computes the MD5 and CRC32C (Castagnoli) checksums of a local
+// file in a single pass.
+func fileChecksums(localPath string) (md5Sum []byte, crc32c uint32, err error) {
+	f, err := os.Open(localPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	md5Hash := md5.New()
+	crcHash := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	if _, err := io.Copy(io.MultiWriter(md5Hash, crcHash), f); err != nil {
+		return nil, 0, err
+	}
+	return md5Hash.Sum(nil), crcHash.Sum32(), nil
+}
+
+// ParseGCSPath splits a gs:// URI into its bucket and object key. The key
+// may be empty (the bucket root) or end in "/" when the URI names a prefix
+// rather than a concrete object; callers that need an object should reject
+// an empty key.
+func ParseGCSPath(uri string) (bucket, key string, err error) {
+	trimmed, ok := strings.CutPrefix(uri, "gs://")
+	if !ok {
+		return "", "", fmt.Errorf("path %q does not start with gs://", uri)
+	}
+	bucket, key, _ = strings.Cut(trimmed, "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("path %q has no bucket", uri)
+	}
+	return bucket, key, nil
+}
+
+// MockStorageClient is a StorageClient test double that counts uploads.
+type MockStorageClient struct {
+	mu sync.Mutex
+
+	// Err, when set, is returned from every UploadFile call.
+	Err error
+	// FailFirst makes the first N UploadFile calls fail with a transient
+	// error before uploads start succeeding.
+	FailFirst int
+	// VerifyChecksums records whether checksum verification was requested.
+	VerifyChecksums bool
+	// Metadata records the custom object metadata last set.
+	Metadata map[string]string
+	// Progress, when set, is invoked once per successful upload with the
+	// file's size as both done and total bytes.
+	Progress ProgressFunc
+
+	UploadCount   int
+	UploadedFiles []string
+	RemotePaths   []string
+
+	// ReaderUploads and ReaderData record reader-based uploads: the remote
+	// paths in call order and the bytes streamed to each.
+	ReaderUploads []string
+	ReaderData    map[string][]byte
+
+	DownloadCount     int
+	DownloadedObjects []string
+	DownloadedFiles   []string
+
+	// Objects is the canned object listing served by ListObjects.
+	Objects []string
+
+	// ObjectData is the canned content served by OpenObject, keyed by
+	// remote path; OpenedObjects records the paths opened.
+	ObjectData    map[string][]byte
+	OpenedObjects []string
+	// ObjectSizes maps remote paths to sizes reported by StatObject.
+	ObjectSizes map[string]int64
+}
+
+// NewMockStorageClient returns an empty mock.
+func NewMockStorageClient() *MockStorageClient {
+	return &MockStorageClient{}
+}
+
+// UploadFile records the upload without touching any network.
+func (m *MockStorageClient) UploadFile(_ context.Context, localPath, remotePath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	if m.FailFirst > 0 {
+		m.FailFirst--
+		return errors.New("mock transient upload failure")
+	}
+	m.UploadCount++
+	m.UploadedFiles = append(m.UploadedFiles, localPath)
+	m.RemotePaths = append(m.RemotePaths, remotePath)
+	if m.Progress != nil {
+		if info, err := os.Stat(localPath); err == nil {
+			m.Progress(localPath, info.Size(), info.Size())
+		}
+	}
+	return nil
+}
+
+// UploadReader records a reader-based upload, retaining the streamed bytes
+// so tests can assert what was sent.
+func (m *MockStorageClient) UploadReader(_ context.Context, r io.Reader, _ int64, remotePath string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	m.ReaderUploads = append(m.ReaderUploads, remotePath)
+	if m.ReaderData == nil {
+		m.ReaderData = make(map[string][]byte)
+	}
+	m.ReaderData[remotePath] = data
+	return nil
+}
+
+// OpenObject serves the canned ObjectData content for remotePath.
+func (m *MockStorageClient) OpenObject(_ context.Context, remotePath string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	data, ok := m.ObjectData[remotePath]
+	if !ok {
+		return nil, fmt.Errorf("no canned content for %s", remotePath)
+	}
+	m.OpenedObjects = append(m.OpenedObjects, remotePath)
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// DownloadFile records the download and writes a placeholder file containing
+// the remote path so callers can inspect the result.
+func (m *MockStorageClient) DownloadFile(_ context.Context, remotePath, localPath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	if err := writeLocalFile(localPath, strings.NewReader(remotePath)); err != nil {
+		return err
+	}
+	m.DownloadCount++
+	m.DownloadedObjects = append(m.DownloadedObjects, remotePath)
+	m.DownloadedFiles = append(m.DownloadedFiles, localPath)
+	return nil
+}
+
+// ListObjects returns the canned Objects list filtered by prefix.
+func (m *MockStorageClient) ListObjects(_ context.Context, prefix string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	var names []string
+	for _, name := range m.Objects {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// StatObject reports an object from ObjectSizes, or (nil, nil) if absent.
+func (m *MockStorageClient) StatObject(_ context.Context, remotePath string) (*ObjectInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	size, ok := m.ObjectSizes[remotePath]
+	if !ok {
+		return nil, nil
+	}
+	return &ObjectInfo{Name: remotePath, Size: size}, nil
+}
+
+// SignedURL returns a deterministic fake URL so callers can be tested
+// without a real signer.
+func (m *MockStorageClient) SignedURL(_ context.Context, remotePath string, ttl time.Duration) (string, error) {
+	if err := validateTTL(ttl); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://storage.invalid/%s?ttl=%s", remotePath, ttl), nil
+}
+
+// SetProgressFunc implements ProgressReporter.
+func (m *MockStorageClient) SetProgressFunc(fn ProgressFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Progress = fn
+}
+
+// SetVerifyChecksums implements ChecksumVerifier.
+func (m *MockStorageClient) SetVerifyChecksums(verify bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.VerifyChecksums = verify
+}
+
+// SetObjectMetadata implements MetadataSetter.
+func (m *MockStorageClient) SetObjectMetadata(metadata map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Metadata = metadata
+}
+
+// Close implements StorageClient.
+func (m *MockStorageClient) Close() error {
+	return nil
+}
+
+// remoteObjectPath builds the object name for a local file under a prefix.
+func remoteObjectPath(prefix, localPath string) string {
+	name := filepath.Base(localPath)
+	if prefix == "" {
+		return name
+	}
+	return path.Join(prefix, name)
+}
+
+// hashSuffixLen is how many hex digest characters hashedObjectPath inserts
+// into an object name.
+const hashSuffixLen = 8
+
+// hashedObjectPath builds the object name for a local file with a short
+// content hash before the extension, so same-named files from different
+// sources land on distinct objects.
+func hashedObjectPath(prefix, localPath string) (string, error) {
+	digest, err := fileSHA256(localPath)
+	if err != nil {
+		return "", fmt.Errorf("hashing %s: %w", localPath, err)
+	}
+	name := filepath.Base(localPath)
+	ext := path.Ext(name)
+	name = strings.TrimSuffix(name, ext) + "-" + digest[:hashSuffixLen] + ext
+	if prefix == "" {
+		return name, nil
+	}
+	return path.Join(prefix, name), nil
+}
+
+// ManifestEntry records where one uploaded file went. A file coalesced by
+// dedupe gets an entry whose RemotePath points at the object uploaded for the
+// canonical copy, with DuplicateOf naming that copy's local path.
+type ManifestEntry struct {
+	LocalPath   string    `json:"local_path"`
+	RemotePath  string    `json:"remote_path"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+	DuplicateOf string    `json:"duplicate_of,omitempty"`
+}
+
+// UploadManager drives batch uploads through a StorageClient.
+type UploadManager struct {
+	client          StorageClient
+	concurrency     int
+	continueOnError bool
+	maxAttempts     int
+	retryBaseDelay  time.Duration
+	verifyChecksums bool
+	skipExisting    bool
+	dedupe          bool
+	hashNames       bool
+	showProgress    bool
+	dryRun          io.Writer
+
+	manifestMu sync.Mutex
+	manifest   []ManifestEntry
+	deduped    int
+}
+
+// NewUploadManager creates a manager uploading one file at a time.
+func NewUploadManager(client StorageClient) *UploadManager {
+	return NewUploadManagerWithConcurrency(client, 1)
+}
+
+// NewUploadManagerWithConcurrency creates a manager that uploads up to
+// concurrency files in parallel.
+func NewUploadManagerWithConcurrency(client StorageClient, concurrency int) *UploadManager {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &UploadManager{client: client, concurrency: concurrency, maxAttempts: 1}
+}
+
+// SetRetries allows each upload up to maxAttempts tries, sleeping baseDelay
+// before the first retry and doubling the delay after each failure. A zero
+// baseDelay retries immediately, which tests rely on.
+func (m *UploadManager) SetRetries(maxAttempts int, baseDelay time.Duration) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	m.maxAttempts = maxAttempts
+	m.retryBaseDelay = baseDelay
+}
+
+// SetVerifyChecksums asks the storage client to verify each upload against a
+// locally computed checksum. Clients that cannot verify checksums ignore it.
+func (m *UploadManager) SetVerifyChecksums(verify bool) {
+	m.verifyChecksums = verify
+	if v, ok := m.client.(ChecksumVerifier); ok {
+		v.SetVerifyChecksums(verify)
+	}
+}
+
+// SetProgressFunc registers a callback for byte-level upload progress.
+// Clients that cannot report progress ignore it.
+func (m *UploadManager) SetProgressFunc(fn ProgressFunc) {
+	if p, ok := m.client.(ProgressReporter); ok {
+		p.SetProgressFunc(fn)
+	}
+}
+
+// SetObjectMetadata attaches custom key/value metadata to every uploaded
+// object. Clients that cannot set metadata ignore it.
+func (m *UploadManager) SetObjectMetadata(metadata map[string]string) {
+	if s, ok := m.client.(MetadataSetter); ok {
+		s.SetObjectMetadata(metadata)
+	}
+}
+
+// UploadReader streams r to remotePath, for content that never touches
+// disk. It fails when the backend does not support reader-based uploads.
+func (m *UploadManager) UploadReader(ctx context.Context, r io.Reader, size int64, remotePath string) error {
+	uploader, ok := m.client.(ReaderUploader)
+	if !ok {
+		return fmt.Errorf("the storage backend does not support reader uploads")
+	}
+	if err := uploader.UploadReader(ctx, r, size, remotePath); err != nil {
+		return fmt.Errorf("uploading %s: %w", remotePath, err)
+	}
+	m.recordManifest("-", remotePath)
+	return nil
+}
+
+// SetSkipExisting makes UploadFiles skip files whose remote object already
+// exists with the same size, so interrupted batches can be re-run cheaply.
+func (m *UploadManager) SetSkipExisting(skip bool) {
+	m.skipExisting = skip
+}
+
+// shouldSkip reports whether the remote object already exists with the same
+// size as the local file.
+func (m *UploadManager) shouldSkip(ctx context.Context, localPath, remotePath string) bool {
+	info, err := m.client.StatObject(ctx, remotePath)
+	if err != nil || info == nil {
+		return false
+	}
+	stat, err := os.Stat(localPath)
+	if err != nil {
+		return false
+	}
+	return info.Size == stat.Size()
+}
+
+// SetShowProgress renders a terminal progress bar during UploadFiles
+// instead of per-file log lines. The bar only appears when stdout is a
+// terminal; otherwise the log lines are kept.
+func (m *UploadManager) SetShowProgress(show bool) {
+	m.showProgress = show
+}
+
+// SetDryRun makes UploadFiles print each "local -> remote" mapping to w
+// instead of uploading, so a batch can be previewed before it touches a
+// shared bucket. The manifest is still recorded; a nil w restores real
+// uploads.
+func (m *UploadManager) SetDryRun(w io.Writer) {
+	m.dryRun = w
+}
+
+// SetHashNames makes UploadFiles insert a short content hash into each
+// remote object name, so two different files that share a base name cannot
+// collide under the same prefix. The manifest records which local file each
+// hashed name came from.
+func (m *UploadManager) SetHashNames(hash bool) {
+	m.hashNames = hash
+}
+
+// SetDedupe makes UploadFiles hash each file with SHA-256 and upload
+// byte-identical content only once, recording the remaining copies as
+// manifest aliases of the uploaded object.
+func (m *UploadManager) SetDedupe(dedupe bool) {
+	m.dedupe = dedupe
+}
+
+// DedupedCount reports how many duplicate files the most recent UploadFiles
+// call coalesced into already-uploaded objects.
+func (m *UploadManager) DedupedCount() int {
+	m.manifestMu.Lock()
+	defer m.manifestMu.Unlock()
+	return m.deduped
+}
+
+// SetContinueOnError makes UploadFiles keep going after individual upload
+// failures instead of aborting the remaining batch.
+func (m *UploadManager) SetContinueOnError(continueOnError bool) {
+	m.continueOnError = continueOnError
+}
+
+// UploadFiles uploads each local file under remotePrefix, naming the object
+// after the file's base name. Unless continue-on-error is set, the first
+// failure cancels the uploads that have not started yet.
+func (m *UploadManager) UploadFiles(ctx context.Context, localPaths []string, remotePrefix string) error {
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(m.concurrency)
+
+	m.manifestMu.Lock()
+	m.manifest = nil
+	m.deduped = 0
+	m.manifestMu.Unlock()
+
+	var aliases map[string]string
+	if m.dedupe {
+		localPaths, aliases = dedupeByContent(localPaths)
+	}
+
+	var bar *progress.Bar
+	if m.showProgress {
+		bar = progress.NewBar(os.Stdout, len(localPaths))
+	}
+	defer bar.Finish()
+	eta := progress.NewETA(len(localPaths), 0)
+
+	var started, skipped atomic.Int64
+	var errsMu, dryRunMu sync.Mutex
+	var errs []error
+	for _, localPath := range localPaths {
+		localPath := localPath
+		g.Go(func() error {
+			if gctx.Err() != nil {
+				return gctx.Err()
+			}
+			remotePath := remoteObjectPath(remotePrefix, localPath)
+			if m.hashNames {
+				hashed, err := hashedObjectPath(remotePrefix, localPath)
+				if err != nil {
+					if !m.continueOnError {
+						return err
+					}
+					slog.Error("upload failed", "file", localPath, "error", err)
+					errsMu.Lock()
+					errs = append(errs, err)
+					errsMu.Unlock()
+					return nil
+				}
+				remotePath = hashed
+			}
+			if m.dryRun != nil {
+				dryRunMu.Lock()
+				fmt.Fprintf(m.dryRun, "%s -> %s\n", localPath, remotePath)
+				dryRunMu.Unlock()
+				m.recordManifest(localPath, remotePath)
+				return nil
+			}
+			if m.skipExisting && m.shouldSkip(gctx, localPath, remotePath) {
+				skipped.Add(1)
+				slog.Info("skipping existing object", "file", localPath, "remote", remotePath)
+				return nil
+			}
+			if bar.Enabled() {
+				started.Add(1)
+				bar.Step(localPath)
+			} else {
+				slog.Info("uploading file",
+					"file", localPath,
+					"remote", remotePath,
+					"progress", fmt.Sprintf("%d/%d", started.Add(1), len(localPaths)),
+				)
+			}
+			uploadStart := time.Now()
+			if err := m.uploadWithRetry(gctx, localPath, remotePath); err != nil {
+				err = fmt.Errorf("uploading %s: %w", localPath, err)
+				if !m.continueOnError {
+					return err
+				}
+				slog.Error("upload failed", "file", localPath, "error", err)
+				errsMu.Lock()
+				errs = append(errs, err)
+				errsMu.Unlock()
+				return nil
+			}
+			if left := eta.Record(time.Since(uploadStart)); left > 0 && !bar.Enabled() {
+				slog.Info("upload finished", "file", localPath, "eta", left.Round(time.Second))
+			}
+			m.recordManifest(localPath, remotePath)
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+	m.recordAliases(aliases)
+	slog.Info("upload complete",
+		"files", len(localPaths)-len(errs)-int(skipped.Load()),
+		"skipped", skipped.Load(),
+		"deduped", m.DedupedCount(),
+		"failed", len(errs),
+	)
+	return errors.Join(errs...)
+}
+
+// dedupeByContent hashes the files and keeps only the first of each group of
+// byte-identical ones, mapping the rest to that canonical copy. Files that
+// cannot be hashed are uploaded normally.
+func dedupeByContent(localPaths []string) (uploads []string, aliases map[string]string) {
+	aliases = make(map[string]string)
+	byHash := make(map[string]string)
+	for _, localPath := range localPaths {
+		sum, err := fileSHA256(localPath)
+		if err != nil {
+			slog.Warn("hashing for dedupe failed; uploading anyway", "file", localPath, "error", err)
+			uploads = append(uploads, localPath)
+			continue
+		}
+		if canonical, ok := byHash[sum]; ok {
+			slog.Info("coalescing duplicate file", "file", localPath, "canonical", canonical)
+			aliases[localPath] = canonical
+			continue
+		}
+		byHash[sum] = localPath
+		uploads = append(uploads, localPath)
+	}
+	return uploads, aliases
+}
+
+// recordAliases adds manifest entries for deduped files whose canonical copy
+// was uploaded, pointing them at the canonical object.
+func (m *UploadManager) recordAliases(aliases map[string]string) {
+	if len(aliases) == 0 {
+		return
+	}
+	remotes := make(map[string]string)
+	for _, entry := range m.Manifest() {
+		remotes[entry.LocalPath] = entry.RemotePath
+	}
+	for localPath, canonical := range aliases {
+		remotePath, ok := remotes[canonical]
+		if !ok {
+			continue
+		}
+		entry := ManifestEntry{
+			LocalPath:   localPath,
+			RemotePath:  remotePath,
+			ContentType: contentTypeForFile(localPath),
+			UploadedAt:  time.Now().UTC(),
+			DuplicateOf: canonical,
+		}
+		if stat, err := os.Stat(localPath); err == nil {
+			entry.Size = stat.Size()
+		}
+		m.manifestMu.Lock()
+		m.manifest = append(m.manifest, entry)
+		m.deduped++
+		m.manifestMu.Unlock()
+	}
+}
+
+// recordManifest appends a manifest entry for a completed upload.
+func (m *UploadManager) recordManifest(localPath, remotePath string) {
+	entry := ManifestEntry{
+		LocalPath:   localPath,
+		RemotePath:  remotePath,
+		ContentType: contentTypeForFile(localPath),
+		UploadedAt:  time.Now().UTC(),
+	}
+	if stat, err := os.Stat(localPath); err == nil {
+		entry.Size = stat.Size()
+	}
+	m.manifestMu.Lock()
+	m.manifest = append(m.manifest, entry)
+	m.manifestMu.Unlock()
+}
+
+// Manifest returns the entries recorded during the most recent UploadFiles
+// call, one per successfully uploaded file.
+func (m *UploadManager) Manifest() []ManifestEntry {
+	m.manifestMu.Lock()
+	defer m.manifestMu.Unlock()
+	entries := make([]ManifestEntry, len(m.manifest))
+	copy(entries, m.manifest)
+	return entries
+}
+
+// WriteManifest writes the manifest of the most recent UploadFiles call to
+// path as indented JSON.
+func (m *UploadManager) WriteManifest(path string) error {
+	data, err := json.MarshalIndent(m.Manifest(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// uploadWithRetry uploads one file, retrying with exponential backoff up to
+// the configured attempt limit.
+func (m *UploadManager) uploadWithRetry(ctx context.Context, localPath, remotePath string) error {
+	delay := m.retryBaseDelay
+	var lastErr error
+	for attempt := 1; attempt <= m.maxAttempts; attempt++ {
+		lastErr = m.client.UploadFile(ctx, localPath, remotePath)
+		if lastErr == nil {
+			return nil
+		}
+		if attempt == m.maxAttempts {
+			break
+		}
+		slog.Warn("retrying upload",
+			"file", localPath,
+			"attempt", attempt,
+			"delay", delay,
+			"error", lastErr,
+		)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+	return lastErr
+}
+
+// DownloadObjects lists the objects under prefix and downloads them into
+// outputDir, preserving their paths relative to the prefix. Up to
+// concurrency downloads run in parallel. It returns the local paths written.
+func DownloadObjects(ctx context.Context, client StorageClient, prefix, outputDir string, concurrency int) ([]string, error) {
+	names, err := client.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	locals := make([]string, len(names))
+	for i, name := range names {
+		i, name := i, name
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, prefix), "/")
+		if rel == "" {
+			rel = path.Base(name)
+		}
+		locals[i] = filepath.Join(outputDir, filepath.FromSlash(rel))
+		g.Go(func() error {
+			slog.Info("downloading object", "object", name, "file", locals[i])
+			return client.DownloadFile(gctx, name, locals[i])
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return locals, nil
+}
+
+// Close releases the underlying storage client.
+func (m *UploadManager) Close() error {
+	return m.client.Close()
+}
@@ -0,0 +1,648 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/api/option"
+)
+
+var (
+	_ StorageClient = (*GCSClient)(nil)
+	_ StorageClient = (*MinIOClient)(nil)
+	_ StorageClient = (*MockStorageClient)(nil)
+
+	_ URLSigner = (*GCSClient)(nil)
+	_ URLSigner = (*MinIOClient)(nil)
+	_ URLSigner = (*MockStorageClient)(nil)
+
+	_ ReaderUploader = (*GCSClient)(nil)
+	_ ReaderUploader = (*MinIOClient)(nil)
+	_ ReaderUploader = (*LocalStorageClient)(nil)
+	_ ReaderUploader = (*MockStorageClient)(nil)
+)
+
+// uploadOnlyClient implements just the core StorageClient interface, with
+// none of the optional capabilities.
+type uploadOnlyClient struct {
+	StorageClient
+}
+
+func TestParseGCSPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		bucket  string
+		key     string
+		wantErr bool
+	}{
+		{"gs://my-bucket/chunks", "my-bucket", "chunks", false},
+		{"gs://my-bucket/chunks/", "my-bucket", "chunks/", false},
+		{"gs://my-bucket/a/b/c.mp4", "my-bucket", "a/b/c.mp4", false},
+		{"gs://my-bucket", "my-bucket", "", false},
+		{"gs://my-bucket/", "my-bucket", "", false},
+		{"my-bucket/chunks", "", "", true},
+		{"https://example.com/chunks", "", "", true},
+		{"gs://", "", "", true},
+		{"", "", "", true},
+	}
+	for _, tt := range tests {
+		bucket, key, err := ParseGCSPath(tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseGCSPath(%q) succeeded, want error", tt.path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseGCSPath(%q): %v", tt.path, err)
+			continue
+		}
+		if bucket != tt.bucket || key != tt.key {
+			t.Errorf("ParseGCSPath(%q) = (%q, %q), want (%q, %q)", tt.path, bucket, key, tt.bucket, tt.key)
+		}
+		if tt.key == "" {
+			continue
+		}
+		// Reassembling the parts must round-trip back to the same URI.
+		if rebuilt := "gs://" + bucket + "/" + key; rebuilt != tt.path {
+			t.Errorf("round trip of %q produced %q", tt.path, rebuilt)
+		}
+	}
+}
+
+func TestUploadManagerUploadsAllFiles(t *testing.T) {
+	mock := NewMockStorageClient()
+	manager := NewUploadManager(mock)
+
+	files := []string{"/tmp/chunks/chunk_001.mp4", "/tmp/chunks/chunk_002.mp4"}
+	if err := manager.UploadFiles(context.Background(), files, "trip/chunks"); err != nil {
+		t.Fatalf("UploadFiles: %v", err)
+	}
+	if mock.UploadCount != 2 {
+		t.Errorf("UploadCount = %d, want 2", mock.UploadCount)
+	}
+	if mock.RemotePaths[0] != "trip/chunks/chunk_001.mp4" {
+		t.Errorf("first remote path = %q", mock.RemotePaths[0])
+	}
+}
+
+func TestUploadManagerParallelUploadsAllFiles(t *testing.T) {
+	mock := NewMockStorageClient()
+	manager := NewUploadManagerWithConcurrency(mock, 4)
+
+	var files []string
+	for i := 1; i <= 20; i++ {
+		files = append(files, fmt.Sprintf("/tmp/chunks/chunk_%03d.mp4", i))
+	}
+	if err := manager.UploadFiles(context.Background(), files, "chunks"); err != nil {
+		t.Fatalf("UploadFiles: %v", err)
+	}
+	if mock.UploadCount != len(files) {
+		t.Errorf("UploadCount = %d, want %d", mock.UploadCount, len(files))
+	}
+}
+
+func TestUploadManagerContinueOnError(t *testing.T) {
+	mock := NewMockStorageClient()
+	mock.Err = errors.New("transient failure")
+	manager := NewUploadManagerWithConcurrency(mock, 2)
+	manager.SetContinueOnError(true)
+
+	err := manager.UploadFiles(context.Background(), []string{"a.mp4", "b.mp4"}, "chunks")
+	if err == nil {
+		t.Fatal("expected joined upload errors")
+	}
+	if !strings.Contains(err.Error(), "a.mp4") || !strings.Contains(err.Error(), "b.mp4") {
+		t.Errorf("error %q should mention both failed files", err)
+	}
+}
+
+func TestContentTypeForFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"chunks/chunk_001.mp4", "video/mp4"},
+		{"frame.jpg", "image/jpeg"},
+		{"audio.wav", "audio/wav"},
+		{"clip.MOV", "video/quicktime"},
+		{"mystery.chunk", "application/octet-stream"},
+	}
+	for _, tt := range tests {
+		if got := contentTypeForFile(tt.path); got != tt.want {
+			t.Errorf("contentTypeForFile(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestFileChecksums(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chunk.bin")
+	if err := os.WriteFile(path, []byte("The quick brown fox jumps over the lazy dog"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	md5Sum, crc, err := fileChecksums(path)
+	if err != nil {
+		t.Fatalf("fileChecksums: %v", err)
+	}
+	if got, want := hex.EncodeToString(md5Sum), "9e107d9d372bb6826bd81d3542a419d6"; got != want {
+		t.Errorf("MD5 = %s, want %s", got, want)
+	}
+	if want := uint32(0x22620404); crc != want {
+		t.Errorf("CRC32C = %#x, want %#x", crc, want)
+	}
+}
+
+func TestUploadManagerSetVerifyChecksums(t *testing.T) {
+	mock := NewMockStorageClient()
+	manager := NewUploadManager(mock)
+	manager.SetVerifyChecksums(true)
+	if !mock.VerifyChecksums {
+		t.Error("SetVerifyChecksums was not forwarded to the client")
+	}
+}
+
+func TestMockStorageClientSignedURL(t *testing.T) {
+	mock := NewMockStorageClient()
+
+	url, err := mock.SignedURL(context.Background(), "chunks/chunk_001.mp4", 15*time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL: %v", err)
+	}
+	if want := "https://storage.invalid/chunks/chunk_001.mp4?ttl=15m0s"; url != want {
+		t.Errorf("SignedURL = %q, want %q", url, want)
+	}
+
+	if _, err := mock.SignedURL(context.Background(), "chunks/chunk_001.mp4", 0); err == nil {
+		t.Error("SignedURL accepted a zero ttl")
+	}
+}
+
+func TestMockStorageClientListObjects(t *testing.T) {
+	mock := NewMockStorageClient()
+	mock.Objects = []string{
+		"chunks/chunk_001.mp4",
+		"chunks/chunk_002.mp4",
+		"other/chunk_001.mp4",
+	}
+
+	names, err := mock.ListObjects(context.Background(), "chunks/")
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	want := []string{"chunks/chunk_001.mp4", "chunks/chunk_002.mp4"}
+	if len(names) != len(want) {
+		t.Fatalf("ListObjects returned %d names, want %d", len(names), len(want))
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("names[%d] = %q, want %q", i, names[i], want[i])
+		}
+	}
+}
+
+func TestDownloadObjects(t *testing.T) {
+	mock := NewMockStorageClient()
+	mock.Objects = []string{
+		"chunks/chunk_001.mp4",
+		"chunks/nested/chunk_002.mp4",
+		"other/chunk_003.mp4",
+	}
+	outputDir := t.TempDir()
+
+	files, err := DownloadObjects(context.Background(), mock, "chunks/", outputDir, 2)
+	if err != nil {
+		t.Fatalf("DownloadObjects: %v", err)
+	}
+	if mock.DownloadCount != 2 {
+		t.Errorf("DownloadCount = %d, want 2", mock.DownloadCount)
+	}
+	want := []string{
+		filepath.Join(outputDir, "chunk_001.mp4"),
+		filepath.Join(outputDir, "nested", "chunk_002.mp4"),
+	}
+	if len(files) != len(want) {
+		t.Fatalf("DownloadObjects returned %d files, want %d", len(files), len(want))
+	}
+	for i := range want {
+		if files[i] != want[i] {
+			t.Errorf("files[%d] = %q, want %q", i, files[i], want[i])
+		}
+		if _, err := os.Stat(want[i]); err != nil {
+			t.Errorf("downloaded file missing: %v", err)
+		}
+	}
+}
+
+func TestMockStorageClientDownloadFile(t *testing.T) {
+	mock := NewMockStorageClient()
+	local := filepath.Join(t.TempDir(), "nested", "chunk_001.mp4")
+
+	if err := mock.DownloadFile(context.Background(), "chunks/chunk_001.mp4", local); err != nil {
+		t.Fatalf("DownloadFile: %v", err)
+	}
+	if mock.DownloadCount != 1 {
+		t.Errorf("DownloadCount = %d, want 1", mock.DownloadCount)
+	}
+	if mock.DownloadedObjects[0] != "chunks/chunk_001.mp4" {
+		t.Errorf("downloaded object = %q", mock.DownloadedObjects[0])
+	}
+	data, err := os.ReadFile(local)
+	if err != nil {
+		t.Fatalf("reading downloaded file: %v", err)
+	}
+	if string(data) != "chunks/chunk_001.mp4" {
+		t.Errorf("downloaded content = %q", data)
+	}
+}
+
+func TestUploadManagerProgressCallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chunk_001.mp4")
+	payload := []byte("not really a video")
+	if err := os.WriteFile(path, payload, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := NewMockStorageClient()
+	manager := NewUploadManager(mock)
+	var gotFile string
+	var gotDone, gotTotal int64
+	manager.SetProgressFunc(func(file string, bytesDone, bytesTotal int64) {
+		gotFile, gotDone, gotTotal = file, bytesDone, bytesTotal
+	})
+
+	if err := manager.UploadFiles(context.Background(), []string{path}, "chunks"); err != nil {
+		t.Fatalf("UploadFiles: %v", err)
+	}
+	if gotFile != path {
+		t.Errorf("progress file = %q, want %q", gotFile, path)
+	}
+	if gotDone != int64(len(payload)) || gotTotal != int64(len(payload)) {
+		t.Errorf("progress bytes = %d/%d, want %d/%d", gotDone, gotTotal, len(payload), len(payload))
+	}
+}
+
+func TestUploadManagerSkipExisting(t *testing.T) {
+	dir := t.TempDir()
+	same := filepath.Join(dir, "chunk_001.mp4")
+	changed := filepath.Join(dir, "chunk_002.mp4")
+	if err := os.WriteFile(same, []byte("12345678"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(changed, []byte("12345678"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := NewMockStorageClient()
+	mock.ObjectSizes = map[string]int64{
+		"chunks/chunk_001.mp4": 8, // matches: skipped
+		"chunks/chunk_002.mp4": 4, // size differs: re-uploaded
+	}
+	manager := NewUploadManager(mock)
+	manager.SetSkipExisting(true)
+
+	if err := manager.UploadFiles(context.Background(), []string{same, changed}, "chunks"); err != nil {
+		t.Fatalf("UploadFiles: %v", err)
+	}
+	if mock.UploadCount != 1 {
+		t.Errorf("UploadCount = %d, want 1", mock.UploadCount)
+	}
+	if len(mock.UploadedFiles) != 1 || mock.UploadedFiles[0] != changed {
+		t.Errorf("UploadedFiles = %v, want only %q", mock.UploadedFiles, changed)
+	}
+}
+
+func TestUploadManagerManifest(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for _, name := range []string{"chunk_001.mp4", "chunk_002.mp4"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("chunk data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+
+	mock := NewMockStorageClient()
+	manager := NewUploadManager(mock)
+	if err := manager.UploadFiles(context.Background(), files, "chunks"); err != nil {
+		t.Fatalf("UploadFiles: %v", err)
+	}
+
+	entries := manager.Manifest()
+	if len(entries) != len(files) {
+		t.Fatalf("Manifest has %d entries, want %d", len(entries), len(files))
+	}
+	for i, entry := range entries {
+		if entry.LocalPath != mock.UploadedFiles[i] {
+			t.Errorf("entry %d local path = %q, want %q", i, entry.LocalPath, mock.UploadedFiles[i])
+		}
+		if entry.RemotePath != mock.RemotePaths[i] {
+			t.Errorf("entry %d remote path = %q, want %q", i, entry.RemotePath, mock.RemotePaths[i])
+		}
+		if entry.Size != int64(len("chunk data")) {
+			t.Errorf("entry %d size = %d, want %d", i, entry.Size, len("chunk data"))
+		}
+		if entry.ContentType != "video/mp4" {
+			t.Errorf("entry %d content type = %q", i, entry.ContentType)
+		}
+		if entry.UploadedAt.IsZero() {
+			t.Errorf("entry %d has no upload timestamp", i)
+		}
+	}
+
+	manifestPath := filepath.Join(dir, "manifest.json")
+	if err := manager.WriteManifest(manifestPath); err != nil {
+		t.Fatalf("WriteManifest: %v", err)
+	}
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []ManifestEntry
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("manifest is not valid JSON: %v", err)
+	}
+	if len(decoded) != len(files) {
+		t.Errorf("written manifest has %d entries, want %d", len(decoded), len(files))
+	}
+}
+
+func TestUploadManagerDedupesIdenticalFiles(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"chunk_001.mp4": "same bytes",
+		"chunk_002.mp4": "different bytes",
+		"chunk_003.mp4": "same bytes",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	canonical := filepath.Join(dir, "chunk_001.mp4")
+	duplicate := filepath.Join(dir, "chunk_003.mp4")
+	files := []string{canonical, filepath.Join(dir, "chunk_002.mp4"), duplicate}
+
+	mock := NewMockStorageClient()
+	manager := NewUploadManager(mock)
+	manager.SetDedupe(true)
+	if err := manager.UploadFiles(context.Background(), files, "chunks"); err != nil {
+		t.Fatalf("UploadFiles: %v", err)
+	}
+
+	if mock.UploadCount != 2 {
+		t.Errorf("UploadCount = %d, want 2 (duplicate coalesced)", mock.UploadCount)
+	}
+	if got := manager.DedupedCount(); got != 1 {
+		t.Errorf("DedupedCount() = %d, want 1", got)
+	}
+	entries := manager.Manifest()
+	if len(entries) != len(files) {
+		t.Fatalf("Manifest has %d entries, want %d", len(entries), len(files))
+	}
+	var alias *ManifestEntry
+	for i := range entries {
+		if entries[i].LocalPath == duplicate {
+			alias = &entries[i]
+		}
+	}
+	if alias == nil {
+		t.Fatal("no manifest entry for the deduped file")
+	}
+	if alias.RemotePath != "chunks/chunk_001.mp4" {
+		t.Errorf("alias remote path = %q, want the canonical object", alias.RemotePath)
+	}
+	if alias.DuplicateOf != canonical {
+		t.Errorf("alias DuplicateOf = %q, want %q", alias.DuplicateOf, canonical)
+	}
+}
+
+func TestUploadManagerHashNamesAvoidsCollisions(t *testing.T) {
+	dirA := t.TempDir()
+	dirB := t.TempDir()
+	fileA := filepath.Join(dirA, "chunk_001.mp4")
+	fileB := filepath.Join(dirB, "chunk_001.mp4")
+	if err := os.WriteFile(fileA, []byte("clip from source A"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fileB, []byte("clip from source B"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mock := NewMockStorageClient()
+	manager := NewUploadManager(mock)
+	manager.SetHashNames(true)
+	if err := manager.UploadFiles(context.Background(), []string{fileA, fileB}, "chunks"); err != nil {
+		t.Fatalf("UploadFiles: %v", err)
+	}
+
+	entries := manager.Manifest()
+	if len(entries) != 2 {
+		t.Fatalf("Manifest has %d entries, want 2", len(entries))
+	}
+	remote := map[string]string{}
+	for _, entry := range entries {
+		remote[entry.LocalPath] = entry.RemotePath
+	}
+	if remote[fileA] == remote[fileB] {
+		t.Fatalf("both files uploaded to %q, want distinct remote paths", remote[fileA])
+	}
+	for local, path := range remote {
+		if !strings.HasPrefix(path, "chunks/chunk_001-") || !strings.HasSuffix(path, ".mp4") {
+			t.Errorf("remote path for %s = %q, want chunks/chunk_001-<hash>.mp4", local, path)
+		}
+	}
+}
+
+func TestUploadManagerUploadReader(t *testing.T) {
+	mock := NewMockStorageClient()
+	manager := NewUploadManager(mock)
+	data := []byte("streamed chunk bytes")
+
+	err := manager.UploadReader(context.Background(), bytes.NewReader(data), int64(len(data)), "chunks/stdin.bin")
+	if err != nil {
+		t.Fatalf("UploadReader: %v", err)
+	}
+
+	if len(mock.ReaderUploads) != 1 || mock.ReaderUploads[0] != "chunks/stdin.bin" {
+		t.Errorf("ReaderUploads = %v, want one upload to chunks/stdin.bin", mock.ReaderUploads)
+	}
+	if got := mock.ReaderData["chunks/stdin.bin"]; !bytes.Equal(got, data) {
+		t.Errorf("streamed bytes = %q, want %q", got, data)
+	}
+	entries := manager.Manifest()
+	if len(entries) != 1 || entries[0].RemotePath != "chunks/stdin.bin" {
+		t.Errorf("Manifest = %+v, want the streamed object recorded", entries)
+	}
+}
+
+func TestUploadManagerUploadReaderUnsupportedBackend(t *testing.T) {
+	manager := NewUploadManager(&uploadOnlyClient{})
+	err := manager.UploadReader(context.Background(), bytes.NewReader(nil), 0, "chunks/stdin.bin")
+	if err == nil {
+		t.Fatal("expected an error for a backend without reader uploads")
+	}
+}
+
+func TestUploadManagerRetriesTransientFailures(t *testing.T) {
+	mock := NewMockStorageClient()
+	mock.FailFirst = 2
+	manager := NewUploadManager(mock)
+	manager.SetRetries(3, 0)
+
+	if err := manager.UploadFiles(context.Background(), []string{"a.mp4"}, "chunks"); err != nil {
+		t.Fatalf("UploadFiles: %v", err)
+	}
+	if mock.UploadCount != 1 {
+		t.Errorf("UploadCount = %d, want 1", mock.UploadCount)
+	}
+}
+
+func TestUploadManagerRetriesExhausted(t *testing.T) {
+	mock := NewMockStorageClient()
+	mock.FailFirst = 3
+	manager := NewUploadManager(mock)
+	manager.SetRetries(2, 0)
+
+	if err := manager.UploadFiles(context.Background(), []string{"a.mp4"}, "chunks"); err == nil {
+		t.Fatal("expected upload to fail after exhausting retries")
+	}
+	if mock.UploadCount != 0 {
+		t.Errorf("UploadCount = %d, want 0", mock.UploadCount)
+	}
+}
+
+func TestMinIOClientCloseIdempotent(t *testing.T) {
+	client, err := NewMinIOClient("localhost:9000", "access", "secret", "test-bucket", false)
+	if err != nil {
+		t.Fatalf("NewMinIOClient: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("first Close: %v", err)
+	}
+	if err := client.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+func TestNewGCSClientWithCredentialsFile(t *testing.T) {
+	bogus := filepath.Join(t.TempDir(), "does-not-exist.json")
+	_, err := NewGCSClientWithCredentialsFile(context.Background(), "test-project", "test-bucket", bogus)
+	if err == nil {
+		t.Error("expected an error for a nonexistent credentials file")
+	}
+}
+
+func TestGCSClientOptions(t *testing.T) {
+	opts := gcsClientOptions("", "http://localhost:4443/storage/v1/", true)
+	if len(opts) != 2 {
+		t.Fatalf("got %d options, want endpoint + no-auth", len(opts))
+	}
+	if !reflect.DeepEqual(opts[0], option.WithEndpoint("http://localhost:4443/storage/v1/")) {
+		t.Errorf("first option = %#v, want the endpoint override", opts[0])
+	}
+	if !reflect.DeepEqual(opts[1], option.WithoutAuthentication()) {
+		t.Errorf("second option = %#v, want authentication disabled", opts[1])
+	}
+
+	if opts := gcsClientOptions("", "", false); len(opts) != 0 {
+		t.Errorf("got %d options with no overrides, want none", len(opts))
+	}
+}
+
+func TestNewGCSClientWithEndpoint(t *testing.T) {
+	client, err := NewGCSClientWithEndpoint(context.Background(), "test-project", "test-bucket", "http://localhost:4443/storage/v1/", true)
+	if err != nil {
+		t.Fatalf("NewGCSClientWithEndpoint: %v", err)
+	}
+	client.Close()
+}
+
+func TestNewGCSClient(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping GCS client creation in short mode")
+	}
+	ctx := context.Background()
+	client, err := NewGCSClient(ctx, "test-project", "test-bucket")
+	if err != nil {
+		// No ambient credentials in most test environments; that's fine.
+		t.Skipf("NewGCSClient: %v", err)
+	}
+	client.Close()
+}
+
+func TestObjectMetadataMergesCustomKeys(t *testing.T) {
+	md := objectMetadata(map[string]string{
+		"trip":        "roadtrip-2024",
+		"uploaded-by": "spoofed",
+	})
+	if md["trip"] != "roadtrip-2024" {
+		t.Errorf(`md["trip"] = %q, want custom value`, md["trip"])
+	}
+	if md["uploaded-by"] != uploadedByMetadata {
+		t.Errorf(`md["uploaded-by"] = %q, want the standard tag to win`, md["uploaded-by"])
+	}
+	if md["upload-time"] == "" {
+		t.Error("upload-time tag missing")
+	}
+}
+
+func TestUploadManagerSetObjectMetadata(t *testing.T) {
+	mock := NewMockStorageClient()
+	manager := NewUploadManager(mock)
+	manager.SetObjectMetadata(map[string]string{"trip": "roadtrip-2024"})
+	if mock.Metadata["trip"] != "roadtrip-2024" {
+		t.Errorf("mock metadata = %v, want the custom pair forwarded", mock.Metadata)
+	}
+}
+
+func TestUploadManagerDryRunSkipsUploads(t *testing.T) {
+	dir := t.TempDir()
+	var files []string
+	for _, name := range []string{"chunk_001.mp4", "chunk_002.mp4"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+
+	mock := NewMockStorageClient()
+	manager := NewUploadManager(mock)
+	var buf bytes.Buffer
+	manager.SetDryRun(&buf)
+	if err := manager.UploadFiles(context.Background(), files, "chunks"); err != nil {
+		t.Fatalf("UploadFiles: %v", err)
+	}
+
+	if mock.UploadCount != 0 {
+		t.Errorf("UploadCount = %d, want 0 in dry-run mode", mock.UploadCount)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	sort.Strings(lines)
+	want := []string{
+		files[0] + " -> chunks/chunk_001.mp4",
+		files[1] + " -> chunks/chunk_002.mp4",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d mappings, want %d: %q", len(lines), len(want), lines)
+	}
+	for i, line := range lines {
+		if line != want[i] {
+			t.Errorf("mapping %d = %q, want %q", i, line, want[i])
+		}
+	}
+	if got := manager.Manifest(); len(got) != 2 {
+		t.Errorf("manifest has %d entries, want 2", len(got))
+	}
+}
@@ -0,0 +1,1204 @@
+// Package video wraps ffmpeg and ffprobe for probing, splitting, and
+// transcoding the source recordings the pipeline works on.
+package video
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"example.com/progress"
+)
+
+// VideoInfo holds the metadata ffprobe reports for a media file.
+type VideoInfo struct {
+	Path       string        `json:"path"`
+	Duration   time.Duration `json:"duration"`
+	Width      int           `json:"width"`
+	Height     int           `json:"height"`
+	FPS        float64       `json:"fps"`
+	VideoCodec string        `json:"video_codec"`
+	AudioCodec string        `json:"audio_codec"`
+	HasAudio   bool          `json:"has_audio"`
+	BitRate    int64         `json:"bit_rate"`
+	Size       int64         `json:"size"`
+}
+
+// ErrNoAudioStream reports that a media file carries no audio stream, so
+// audio-dependent operations have nothing to work on.
+var ErrNoAudioStream = errors.New("no audio stream")
+
+// VideoProcessor runs ffmpeg/ffprobe commands against local files.
+type VideoProcessor struct {
+	ffmpegPath   string
+	ffprobePath  string
+	stdout       io.Writer
+	stderr       io.Writer
+	quiet        bool
+	showProgress bool
+
+	startIndex int // number of the first chunk; 1 when unset
+	padWidth   int // zero-padding width for chunk numbers; 3 when unset
+	nameTmpl   *template.Template
+
+	outputDirPolicy OutputDirPolicy
+
+	audioCodec   string   // chunk audio codec; empty copies the source stream
+	audioBitrate string   // chunk audio bitrate when re-encoding, e.g. "192k"
+	copyMode     bool     // copy streams without re-encoding, snapping cuts to keyframes
+	embedMeta    bool     // tag chunk containers with title, provenance, and track number
+	extraArgs    []string // passed through to ffmpeg just before the output path
+
+	timestampNames bool // name chunks by start offset instead of index
+
+	rendition *Rendition // ABR rung being produced, during SplitVideoMultiRes only
+
+	probeAttempts  int           // ffprobe tries per file; 1 when unset
+	probeBaseDelay time.Duration // sleep before the first ffprobe retry
+}
+
+// OutputDirPolicy controls how SplitVideo treats an output directory that
+// already contains files, e.g. chunks from a previous run.
+type OutputDirPolicy int
+
+const (
+	// OutputDirRefuse aborts the split when the output directory is not
+	// empty, so stale chunks from an earlier, longer run never linger next
+	// to new ones.
+	OutputDirRefuse OutputDirPolicy = iota
+	// OutputDirOverwrite writes into the directory as-is, overwriting
+	// chunks with colliding names and leaving the rest in place.
+	OutputDirOverwrite
+	// OutputDirClean removes the directory's existing contents first.
+	OutputDirClean
+)
+
+// SetOutputDirPolicy selects how SplitVideo handles a non-empty output
+// directory. The default is OutputDirRefuse.
+func (p *VideoProcessor) SetOutputDirPolicy(policy OutputDirPolicy) {
+	p.outputDirPolicy = policy
+}
+
+// prepareOutputDir creates the output directory and applies the configured
+// policy to any existing contents.
+func (p *VideoProcessor) prepareOutputDir(outputDir string) error {
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return fmt.Errorf("reading output directory: %w", err)
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	switch p.outputDirPolicy {
+	case OutputDirOverwrite:
+		return nil
+	case OutputDirClean:
+		if !p.quiet {
+			slog.Info("clearing output directory", "dir", outputDir, "entries", len(entries))
+		}
+		for _, entry := range entries {
+			if err := os.RemoveAll(filepath.Join(outputDir, entry.Name())); err != nil {
+				return fmt.Errorf("clearing output directory: %w", err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("output directory %s already contains %d entries", outputDir, len(entries))
+	}
+}
+
+// NewVideoProcessor locates ffmpeg and ffprobe on PATH.
+func NewVideoProcessor() (*VideoProcessor, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg not found on PATH: %w", err)
+	}
+	ffprobe, err := exec.LookPath("ffprobe")
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe not found on PATH: %w", err)
+	}
+	return &VideoProcessor{
+		ffmpegPath:  ffmpeg,
+		ffprobePath: ffprobe,
+		stdout:      os.Stdout,
+		stderr:      os.Stderr,
+	}, nil
+}
+
+// SetChunkNaming controls chunk filename numbering: startIndex is the number
+// given to the first chunk (so a second pass over the same video can continue
+// where an earlier one stopped) and padWidth the zero-padding width.
+// Non-positive values keep the defaults of 1 and 3.
+func (p *VideoProcessor) SetChunkNaming(startIndex, padWidth int) {
+	p.startIndex = startIndex
+	p.padWidth = padWidth
+}
+
+// chunkFileName renders the filename for the chunk with the given number.
+func (p *VideoProcessor) chunkFileName(index int) string {
+	width := p.padWidth
+	if width <= 0 {
+		width = 3
+	}
+	return fmt.Sprintf("chunk_%0*d.mp4", width, index)
+}
+
+// ChunkNameData is the data available to a chunk name template.
+type ChunkNameData struct {
+	Index int     // chunk number, offset by the configured start index
+	Start float64 // chunk start within the source, in seconds
+	End   float64 // chunk end within the source, in seconds
+	Base  string  // source file basename without extension
+}
+
+// SetNameTemplate installs a text/template rendered for each chunk filename,
+// e.g. "{{.Base}}_{{.Index}}.mp4" — see ChunkNameData for the available
+// variables. The template is parsed and trial-rendered immediately so bad
+// syntax fails before any splitting starts. An empty string keeps the
+// default chunk_NNN.mp4 scheme.
+func (p *VideoProcessor) SetNameTemplate(tmpl string) error {
+	if tmpl == "" {
+		p.nameTmpl = nil
+		return nil
+	}
+	parsed, err := template.New("chunk_name").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("bad name template %q: %w", tmpl, err)
+	}
+	var sb strings.Builder
+	if err := parsed.Execute(&sb, ChunkNameData{Base: "sample"}); err != nil {
+		return fmt.Errorf("bad name template %q: %w", tmpl, err)
+	}
+	p.nameTmpl = parsed
+	return nil
+}
+
+// SetTimestampNames names chunks by their start offset within the source,
+// e.g. clip_00-01-30.mp4, so a moment can be found without opening files.
+// It is a preset alternative to SetNameTemplate; a custom template, when
+// set, still wins. Offsets are unique per chunk, so names cannot collide.
+func (p *VideoProcessor) SetTimestampNames(timestamps bool) {
+	p.timestampNames = timestamps
+}
+
+// timestampName renders the HH-MM-SS filename for a chunk starting at the
+// given offset.
+func timestampName(base string, start float64) string {
+	secs := int(start)
+	return fmt.Sprintf("%s_%02d-%02d-%02d.mp4", base, secs/3600, secs/60%60, secs%60)
+}
+
+// chunkName renders the filename for one chunk, via the name template when
+// one is set.
+func (p *VideoProcessor) chunkName(data ChunkNameData) (string, error) {
+	if p.nameTmpl == nil {
+		if p.timestampNames {
+			return timestampName(data.Base, data.Start), nil
+		}
+		return p.chunkFileName(data.Index), nil
+	}
+	var sb strings.Builder
+	if err := p.nameTmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("rendering chunk name: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// SetQuiet suppresses ffmpeg's console output and the processor's own status
+// logs. The captured ffmpeg output is still surfaced in errors on failure.
+func (p *VideoProcessor) SetQuiet(quiet bool) {
+	p.quiet = quiet
+}
+
+// SetShowProgress renders a terminal progress bar while splitting instead of
+// per-chunk log lines. The bar only appears when stdout is a terminal;
+// otherwise the log lines are kept.
+func (p *VideoProcessor) SetShowProgress(show bool) {
+	p.showProgress = show
+}
+
+// runFFmpeg runs ffmpeg with args, streaming its output through unless the
+// processor is quiet, in which case output is buffered and included only in
+// the error.
+func (p *VideoProcessor) runFFmpeg(args ...string) error {
+	cmd := exec.Command(p.ffmpegPath, args...)
+	if p.quiet {
+		var buf bytes.Buffer
+		cmd.Stdout = &buf
+		cmd.Stderr = &buf
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("ffmpeg: %w\n%s", err, buf.String())
+		}
+		return nil
+	}
+	cmd.Stdout = p.stdout
+	cmd.Stderr = p.stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("ffmpeg: %w", err)
+	}
+	return nil
+}
+
+// ffprobeOutput mirrors the parts of `ffprobe -print_format json` we read.
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType    string `json:"codec_type"`
+		CodecName    string `json:"codec_name"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		AvgFrameRate string `json:"avg_frame_rate"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+		BitRate  string `json:"bit_rate"`
+		Size     string `json:"size"`
+	} `json:"format"`
+}
+
+// SetProbeRetries allows each ffprobe invocation up to maxAttempts tries,
+// sleeping baseDelay before the first retry and doubling the delay after
+// each failure, for files on flaky mounts. The default of 1 attempt
+// preserves the fail-fast behavior; a missing file is never retried.
+func (p *VideoProcessor) SetProbeRetries(maxAttempts int, baseDelay time.Duration) {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	p.probeAttempts = maxAttempts
+	p.probeBaseDelay = baseDelay
+}
+
+// GetVideoInfo probes inputFile with ffprobe and returns its metadata.
+func (p *VideoProcessor) GetVideoInfo(inputFile string) (*VideoInfo, error) {
+	if _, err := os.Stat(inputFile); err != nil {
+		return nil, fmt.Errorf("stat %s: %w", inputFile, err)
+	}
+	attempts := p.probeAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	delay := p.probeBaseDelay
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		out, err := exec.Command(p.ffprobePath,
+			"-v", "quiet",
+			"-print_format", "json",
+			"-show_format",
+			"-show_streams",
+			inputFile,
+		).Output()
+		if err == nil {
+			return parseFFProbeOutput(inputFile, out)
+		}
+		lastErr = fmt.Errorf("ffprobe %s: %w", inputFile, err)
+		if attempt == attempts {
+			break
+		}
+		slog.Warn("retrying ffprobe",
+			"file", inputFile,
+			"attempt", attempt,
+			"delay", delay,
+			"error", lastErr,
+		)
+		time.Sleep(delay)
+		delay *= 2
+	}
+	return nil, lastErr
+}
+
+// BatchVideoInfo probes every path across a worker pool of the given
+// concurrency and returns the metadata keyed by path. Files that fail to
+// probe are left out of the map and their errors joined into the returned
+// error, so one broken chunk does not abort the batch.
+func (p *VideoProcessor) BatchVideoInfo(ctx context.Context, paths []string, concurrency int) (map[string]*VideoInfo, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	infos := make(map[string]*VideoInfo, len(paths))
+	var errs []error
+	for _, path := range paths {
+		path := path
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if err := ctx.Err(); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+			info, err := p.GetVideoInfo(path)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			infos[path] = info
+		}()
+	}
+	wg.Wait()
+	return infos, errors.Join(errs...)
+}
+
+// parseFFProbeOutput converts raw ffprobe JSON into a VideoInfo.
+func parseFFProbeOutput(inputFile string, raw []byte) (*VideoInfo, error) {
+	var probe ffprobeOutput
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, fmt.Errorf("parsing ffprobe output for %s: %w", inputFile, err)
+	}
+
+	info := &VideoInfo{Path: inputFile}
+	if secs, err := strconv.ParseFloat(probe.Format.Duration, 64); err == nil {
+		info.Duration = time.Duration(secs * float64(time.Second))
+	}
+	if bitRate, err := strconv.ParseInt(probe.Format.BitRate, 10, 64); err == nil {
+		info.BitRate = bitRate
+	}
+	if size, err := strconv.ParseInt(probe.Format.Size, 10, 64); err == nil {
+		info.Size = size
+	}
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			info.VideoCodec = stream.CodecName
+			info.Width = stream.Width
+			info.Height = stream.Height
+			info.FPS = parseFrameRate(stream.AvgFrameRate)
+		case "audio":
+			info.AudioCodec = stream.CodecName
+			info.HasAudio = true
+		}
+	}
+	return info, nil
+}
+
+// parseFrameRate converts ffprobe's "num/den" frame rate into a float.
+func parseFrameRate(rate string) float64 {
+	num, den, ok := strings.Cut(rate, "/")
+	if !ok {
+		f, _ := strconv.ParseFloat(rate, 64)
+		return f
+	}
+	n, err1 := strconv.ParseFloat(num, 64)
+	d, err2 := strconv.ParseFloat(den, 64)
+	if err1 != nil || err2 != nil || d == 0 {
+		return 0
+	}
+	return n / d
+}
+
+// SplitVideo cuts the span [startTime, endTime] of inputFile into
+// chunkDuration-second chunks written to outputDir as chunk_001.mp4,
+// chunk_002.mp4, and so on. Times use HH:MM:SS, MM:SS, or plain seconds.
+func (p *VideoProcessor) SplitVideo(inputFile, outputDir, startTime, endTime string, chunkDuration int) error {
+	start, err := parseTime(startTime)
+	if err != nil {
+		return fmt.Errorf("invalid start time %q: %w", startTime, err)
+	}
+	end, err := parseTime(endTime)
+	if err != nil {
+		return fmt.Errorf("invalid end time %q: %w", endTime, err)
+	}
+	if end <= start {
+		return fmt.Errorf("end time %q is not after start time %q", endTime, startTime)
+	}
+	if chunkDuration <= 0 {
+		return fmt.Errorf("chunk duration must be positive, got %d", chunkDuration)
+	}
+	if err := p.prepareOutputDir(outputDir); err != nil {
+		return err
+	}
+
+	var keyframes []float64
+	if p.copyMode {
+		if keyframes, err = p.listKeyframes(inputFile); err != nil {
+			return err
+		}
+	}
+	base := strings.TrimSuffix(filepath.Base(inputFile), filepath.Ext(inputFile))
+	index := p.startIndex
+	if index <= 0 {
+		index = 1
+	}
+	var bar *progress.Bar
+	if p.showProgress {
+		bar = progress.NewBar(os.Stdout, int(math.Ceil((end-start)/float64(chunkDuration))))
+	}
+	defer bar.Finish()
+	for chunkStart := start; chunkStart < end; chunkStart += float64(chunkDuration) {
+		duration := float64(chunkDuration)
+		if remaining := end - chunkStart; remaining < duration {
+			duration = remaining
+		}
+		actualStart, actualEnd := chunkStart, chunkStart+duration
+		if p.copyMode {
+			actualStart = snapToKeyframe(keyframes, chunkStart)
+			if actualEnd < end {
+				actualEnd = snapToKeyframe(keyframes, actualEnd)
+			}
+			if actualEnd <= actualStart {
+				continue
+			}
+			if actualStart != chunkStart && !p.quiet {
+				slog.Info("snapped cut to keyframe", "requested", chunkStart, "snapped", actualStart)
+			}
+		}
+		name, err := p.chunkName(ChunkNameData{
+			Index: index,
+			Start: actualStart,
+			End:   actualEnd,
+			Base:  base,
+		})
+		if err != nil {
+			return err
+		}
+		outputFile := filepath.Join(outputDir, name)
+		if bar.Enabled() {
+			bar.Step(outputFile)
+		} else if !p.quiet {
+			slog.Info("extracting chunk", "index", index, "start", actualStart, "duration", actualEnd-actualStart, "output", outputFile)
+		}
+		if err := p.extractChunk(inputFile, outputFile, index, actualStart, actualEnd-actualStart); err != nil {
+			return fmt.Errorf("extracting chunk %d: %w", index, err)
+		}
+		index++
+	}
+	return nil
+}
+
+// SplitVideoCount cuts the span [startTime, endTime] of inputFile into
+// exactly numChunks equal-length chunks, deriving the per-chunk duration
+// from the span instead of taking it as an input.
+func (p *VideoProcessor) SplitVideoCount(inputFile, outputDir, startTime, endTime string, numChunks int) error {
+	start, err := parseTime(startTime)
+	if err != nil {
+		return fmt.Errorf("invalid start time %q: %w", startTime, err)
+	}
+	end, err := parseTime(endTime)
+	if err != nil {
+		return fmt.Errorf("invalid end time %q: %w", endTime, err)
+	}
+	if end <= start {
+		return fmt.Errorf("end time %q is not after start time %q", endTime, startTime)
+	}
+	if numChunks <= 0 {
+		return fmt.Errorf("number of chunks must be positive, got %d", numChunks)
+	}
+	chunkDuration := (end - start) / float64(numChunks)
+	segments := make([]Segment, numChunks)
+	for i := range segments {
+		segments[i] = Segment{
+			Start: start + float64(i)*chunkDuration,
+			End:   start + float64(i+1)*chunkDuration,
+		}
+	}
+	// Pin the last boundary to the requested end so rounding never drops
+	// the final fraction of a second.
+	segments[numChunks-1].End = end
+	return p.SplitVideoFromEDL(inputFile, outputDir, segments)
+}
+
+// Segment is one time range to extract from a source recording, typically
+// parsed from a cue/EDL file.
+type Segment struct {
+	Start float64 // seconds from the start of the source
+	End   float64 // seconds from the start of the source
+	Label string  // optional name woven into the chunk filename
+}
+
+// ParseEDL reads segments from a simple cue list: one "start,end[,label]"
+// line per segment, with times in any format parseTime accepts (HH:MM:SS,
+// MM:SS, seconds, or Go durations). Blank lines and lines starting with '#'
+// are skipped.
+func ParseEDL(r io.Reader) ([]Segment, error) {
+	var segments []Segment
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, ",", 3)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("line %d: want start,end[,label], got %q", lineNo, line)
+		}
+		start, err := parseTime(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid start time %q: %w", lineNo, fields[0], err)
+		}
+		end, err := parseTime(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid end time %q: %w", lineNo, fields[1], err)
+		}
+		if end <= start {
+			return nil, fmt.Errorf("line %d: end %q is not after start %q", lineNo, fields[1], fields[0])
+		}
+		segment := Segment{Start: start, End: end}
+		if len(fields) == 3 {
+			segment.Label = strings.TrimSpace(fields[2])
+		}
+		segments = append(segments, segment)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("no segments in EDL")
+	}
+	return segments, nil
+}
+
+// SplitVideoFromEDL extracts one chunk per segment instead of cutting the
+// source at a fixed interval. A segment's label, when present, is appended
+// to the chunk filename.
+func (p *VideoProcessor) SplitVideoFromEDL(inputFile, outputDir string, segments []Segment) error {
+	if len(segments) == 0 {
+		return fmt.Errorf("no segments to extract")
+	}
+	if err := p.prepareOutputDir(outputDir); err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(filepath.Base(inputFile), filepath.Ext(inputFile))
+	index := p.startIndex
+	if index <= 0 {
+		index = 1
+	}
+	for _, segment := range segments {
+		name, err := p.chunkName(ChunkNameData{
+			Index: index,
+			Start: segment.Start,
+			End:   segment.End,
+			Base:  base,
+		})
+		if err != nil {
+			return err
+		}
+		if segment.Label != "" {
+			ext := filepath.Ext(name)
+			name = strings.TrimSuffix(name, ext) + "_" + sanitizeLabel(segment.Label) + ext
+		}
+		outputFile := filepath.Join(outputDir, name)
+		if !p.quiet {
+			slog.Info("extracting segment", "index", index, "start", segment.Start, "end", segment.End, "output", outputFile)
+		}
+		if err := p.extractChunk(inputFile, outputFile, index, segment.Start, segment.End-segment.Start); err != nil {
+			return fmt.Errorf("extracting segment %d: %w", index, err)
+		}
+		index++
+	}
+	return nil
+}
+
+// Rendition describes one rung of an ABR ladder: a label woven into the
+// output filename plus the target frame size and video bitrate.
+type Rendition struct {
+	Label   string // e.g. "720p"
+	Width   int
+	Height  int
+	Bitrate string // e.g. "2500k"; empty lets the encoder choose
+}
+
+// renditionArgs returns the ffmpeg scaling and bitrate arguments for r.
+func renditionArgs(r Rendition) []string {
+	args := []string{"-vf", fmt.Sprintf("scale=%d:%d", r.Width, r.Height)}
+	if r.Bitrate != "" {
+		args = append(args, "-b:v", r.Bitrate)
+	}
+	return args
+}
+
+// SplitVideoMultiRes transcodes inputFile into one full-length output per
+// rendition, e.g. chunk_001_720p.mp4 and chunk_001_360p.mp4, so a clip can
+// be served as an ABR ladder. Outputs share the extraction pipeline with
+// SplitVideo, with each rendition's scale filter and bitrate applied.
+func (p *VideoProcessor) SplitVideoMultiRes(inputFile, outputDir string, renditions []Rendition) error {
+	if len(renditions) == 0 {
+		return fmt.Errorf("no renditions to produce")
+	}
+	if p.copyMode {
+		return fmt.Errorf("renditions need re-encoding; disable copy mode")
+	}
+	info, err := p.GetVideoInfo(inputFile)
+	if err != nil {
+		return err
+	}
+	if err := p.prepareOutputDir(outputDir); err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(filepath.Base(inputFile), filepath.Ext(inputFile))
+	index := p.startIndex
+	if index <= 0 {
+		index = 1
+	}
+	duration := info.Duration.Seconds()
+	for i, r := range renditions {
+		if r.Label == "" || r.Width <= 0 || r.Height <= 0 {
+			return fmt.Errorf("rendition %d needs a label and a positive frame size", i+1)
+		}
+		name, err := p.chunkName(ChunkNameData{Index: index, Start: 0, End: duration, Base: base})
+		if err != nil {
+			return err
+		}
+		ext := filepath.Ext(name)
+		name = strings.TrimSuffix(name, ext) + "_" + sanitizeLabel(r.Label) + ext
+		outputFile := filepath.Join(outputDir, name)
+		if !p.quiet {
+			slog.Info("transcoding rendition", "label", r.Label, "size", fmt.Sprintf("%dx%d", r.Width, r.Height), "output", outputFile)
+		}
+		p.rendition = &renditions[i]
+		err = p.extractChunk(inputFile, outputFile, index, 0, duration)
+		p.rendition = nil
+		if err != nil {
+			return fmt.Errorf("transcoding rendition %s: %w", r.Label, err)
+		}
+	}
+	return nil
+}
+
+// sanitizeLabel reduces a segment label to filename-safe characters,
+// mapping spaces to underscores and dropping everything else exotic.
+func sanitizeLabel(label string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		case r == ' ':
+			return '_'
+		default:
+			return -1
+		}
+	}, label)
+}
+
+// SetAudioEncoding re-encodes chunk audio with the given codec (e.g. "aac")
+// and optional bitrate (e.g. "192k") instead of the default stream copy,
+// which preserves the source audio bit-for-bit for music analysis. An empty
+// codec restores the copy behavior.
+func (p *VideoProcessor) SetAudioEncoding(codec, bitrate string) {
+	p.audioCodec = codec
+	p.audioBitrate = bitrate
+}
+
+// SetCopyMode makes chunk extraction copy the source streams instead of
+// re-encoding. Cut points are snapped to the nearest preceding keyframe so
+// copied chunks do not start mid-GOP, giving fast, lossless splits at the
+// cost of chunk boundaries landing slightly before the requested times.
+func (p *VideoProcessor) SetCopyMode(copy bool) {
+	p.copyMode = copy
+}
+
+// ffprobeFrames mirrors the JSON ffprobe emits for -show_frames.
+type ffprobeFrames struct {
+	Frames []struct {
+		PTSTime string `json:"pts_time"`
+	} `json:"frames"`
+}
+
+// listKeyframes returns the presentation timestamps, in seconds, of the
+// keyframes in inputFile's first video stream, sorted ascending.
+func (p *VideoProcessor) listKeyframes(inputFile string) ([]float64, error) {
+	out, err := exec.Command(p.ffprobePath,
+		"-v", "quiet",
+		"-select_streams", "v:0",
+		"-skip_frame", "nokey",
+		"-show_frames",
+		"-show_entries", "frame=pts_time",
+		"-print_format", "json",
+		inputFile,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe keyframes %s: %w", inputFile, err)
+	}
+	return parseKeyframes(out)
+}
+
+// parseKeyframes extracts keyframe timestamps from raw ffprobe -show_frames
+// JSON output.
+func parseKeyframes(raw []byte) ([]float64, error) {
+	var probe ffprobeFrames
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return nil, fmt.Errorf("parsing ffprobe frames: %w", err)
+	}
+	keyframes := make([]float64, 0, len(probe.Frames))
+	for _, frame := range probe.Frames {
+		t, err := strconv.ParseFloat(frame.PTSTime, 64)
+		if err != nil {
+			continue
+		}
+		keyframes = append(keyframes, t)
+	}
+	if len(keyframes) == 0 {
+		return nil, fmt.Errorf("no keyframes in ffprobe output")
+	}
+	sort.Float64s(keyframes)
+	return keyframes, nil
+}
+
+// snapToKeyframe returns the nearest keyframe at or before t, or t
+// unchanged when every keyframe is later.
+func snapToKeyframe(keyframes []float64, t float64) float64 {
+	i := sort.SearchFloat64s(keyframes, t)
+	if i < len(keyframes) && keyframes[i] == t {
+		return t
+	}
+	if i == 0 {
+		return t
+	}
+	return keyframes[i-1]
+}
+
+// chunkAudioArgs returns the ffmpeg audio arguments for chunk extraction.
+func (p *VideoProcessor) chunkAudioArgs() []string {
+	if p.audioCodec == "" {
+		return []string{"-c:a", "copy"}
+	}
+	args := []string{"-c:a", p.audioCodec}
+	if p.audioBitrate != "" {
+		args = append(args, "-b:a", p.audioBitrate)
+	}
+	return args
+}
+
+// SetEmbedMetadata tags each chunk's container with a title, a comment
+// naming the source file and time range, and a track number, so tools that
+// read tags can reconstruct ordering and provenance from the files alone.
+func (p *VideoProcessor) SetEmbedMetadata(embed bool) {
+	p.embedMeta = embed
+}
+
+// chunkMetadataArgs returns the -metadata arguments tagging one chunk, or
+// nil when embedding is off.
+func (p *VideoProcessor) chunkMetadataArgs(inputFile, outputFile string, index int, start, duration float64) []string {
+	if !p.embedMeta {
+		return nil
+	}
+	title := strings.TrimSuffix(filepath.Base(outputFile), filepath.Ext(outputFile))
+	comment := fmt.Sprintf("%s %s-%s", filepath.Base(inputFile), formatSeconds(start), formatSeconds(start+duration))
+	return []string{
+		"-metadata", "title=" + title,
+		"-metadata", "comment=" + comment,
+		"-metadata", fmt.Sprintf("track=%d", index),
+	}
+}
+
+// SetExtraArgs appends args verbatim to every generated chunk-extraction
+// command, after the built-in options and immediately before the output
+// path. Because ffmpeg honors the last occurrence of a flag, extra args can
+// override built-ins like -preset.
+func (p *VideoProcessor) SetExtraArgs(args []string) {
+	p.extraArgs = args
+}
+
+// extractChunkArgs builds the ffmpeg arguments to cut one chunk out of
+// inputFile.
+func (p *VideoProcessor) extractChunkArgs(inputFile, outputFile string, index int, start, duration float64) []string {
+	args := []string{
+		"-y",
+		"-ss", formatSeconds(start),
+		"-i", inputFile,
+		"-t", formatSeconds(duration),
+	}
+	if p.copyMode {
+		args = append(args, "-c", "copy")
+	} else {
+		args = append(args, "-c:v", "libx264", "-preset", "fast")
+		if p.rendition != nil {
+			args = append(args, renditionArgs(*p.rendition)...)
+		}
+		args = append(args, p.chunkAudioArgs()...)
+	}
+	args = append(args, p.chunkMetadataArgs(inputFile, outputFile, index, start, duration)...)
+	args = append(args, p.extraArgs...)
+	return append(args, outputFile)
+}
+
+// extractChunk runs ffmpeg to cut one chunk out of inputFile.
+func (p *VideoProcessor) extractChunk(inputFile, outputFile string, index int, start, duration float64) error {
+	return p.runFFmpeg(p.extractChunkArgs(inputFile, outputFile, index, start, duration)...)
+}
+
+// ExtractAudio pulls the audio track of inputFile into outputFile (format
+// chosen by extension, e.g. .wav or .mp3).
+func (p *VideoProcessor) ExtractAudio(inputFile, outputFile string) error {
+	info, err := p.GetVideoInfo(inputFile)
+	if err != nil {
+		return err
+	}
+	if !info.HasAudio {
+		return fmt.Errorf("extract audio from %s: %w", inputFile, ErrNoAudioStream)
+	}
+	if err := p.runFFmpeg(
+		"-y",
+		"-i", inputFile,
+		"-vn",
+		outputFile,
+	); err != nil {
+		return fmt.Errorf("extract audio: %w", err)
+	}
+	return nil
+}
+
+// mp4VideoCodecs and mp4AudioCodecs list the stream codecs an mp4 container
+// can carry without re-encoding.
+var (
+	mp4VideoCodecs = map[string]bool{"h264": true, "hevc": true, "mpeg4": true, "av1": true}
+	mp4AudioCodecs = map[string]bool{"aac": true, "mp3": true, "ac3": true}
+)
+
+// Remux rewrites inputFile into an mp4 container at outputFile, for sources
+// in containers the analysis API won't accept. When both stream codecs are
+// mp4-compatible the streams are copied bit-for-bit; otherwise the file is
+// transcoded to H.264/AAC. The returned bool reports whether the lossless
+// copy was possible.
+func (p *VideoProcessor) Remux(inputFile, outputFile string) (bool, error) {
+	info, err := p.GetVideoInfo(inputFile)
+	if err != nil {
+		return false, err
+	}
+	if mp4VideoCodecs[info.VideoCodec] && (!info.HasAudio || mp4AudioCodecs[info.AudioCodec]) {
+		if err := p.runFFmpeg("-y", "-i", inputFile, "-c", "copy", outputFile); err != nil {
+			return false, fmt.Errorf("remuxing %s: %w", inputFile, err)
+		}
+		return true, nil
+	}
+	args := []string{"-y", "-i", inputFile, "-c:v", "libx264", "-preset", "fast"}
+	if info.HasAudio {
+		args = append(args, "-c:a", "aac")
+	}
+	args = append(args, outputFile)
+	if err := p.runFFmpeg(args...); err != nil {
+		return false, fmt.Errorf("transcoding %s: %w", inputFile, err)
+	}
+	return false, nil
+}
+
+// loudnormTargets are the EBU R128 loudness targets used for normalization.
+const loudnormTargets = "I=-16:TP=-1.5:LRA=11"
+
+// loudnormMeasurements holds the loudness statistics ffmpeg reports from the
+// measurement pass, fed back into the second pass for an accurate correction.
+type loudnormMeasurements struct {
+	InputI       string `json:"input_i"`
+	InputTP      string `json:"input_tp"`
+	InputLRA     string `json:"input_lra"`
+	InputThresh  string `json:"input_thresh"`
+	TargetOffset string `json:"target_offset"`
+}
+
+// loudnormMeasureArgs builds the ffmpeg arguments for the measurement pass,
+// which analyzes loudness without writing any output.
+func loudnormMeasureArgs(inputFile string) []string {
+	return []string{
+		"-y",
+		"-i", inputFile,
+		"-af", "loudnorm=" + loudnormTargets + ":print_format=json",
+		"-f", "null", "-",
+	}
+}
+
+// loudnormApplyArgs builds the ffmpeg arguments for the correction pass,
+// which applies the measured values while copying the video stream through.
+func loudnormApplyArgs(inputFile, outputFile string, m loudnormMeasurements) []string {
+	filter := fmt.Sprintf("loudnorm=%s:measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:offset=%s:linear=true",
+		loudnormTargets, m.InputI, m.InputTP, m.InputLRA, m.InputThresh, m.TargetOffset)
+	return []string{
+		"-y",
+		"-i", inputFile,
+		"-c:v", "copy",
+		"-af", filter,
+		outputFile,
+	}
+}
+
+// parseLoudnormOutput extracts the JSON statistics block the loudnorm filter
+// prints at the end of the measurement pass's stderr.
+func parseLoudnormOutput(stderr string) (*loudnormMeasurements, error) {
+	start := strings.LastIndex(stderr, "{")
+	end := strings.LastIndex(stderr, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no loudnorm statistics in ffmpeg output")
+	}
+	var m loudnormMeasurements
+	if err := json.Unmarshal([]byte(stderr[start:end+1]), &m); err != nil {
+		return nil, fmt.Errorf("parsing loudnorm statistics: %w", err)
+	}
+	if m.InputI == "" {
+		return nil, fmt.Errorf("loudnorm statistics missing input_i")
+	}
+	return &m, nil
+}
+
+// NormalizeAudio runs ffmpeg's loudnorm filter over inputFile in two passes
+// (measure, then correct) and writes the result to outputFile, copying the
+// video stream unchanged. It returns the measured input loudness in LUFS.
+func (p *VideoProcessor) NormalizeAudio(inputFile, outputFile string) (float64, error) {
+	var stderr strings.Builder
+	cmd := exec.Command(p.ffmpegPath, loudnormMeasureArgs(inputFile)...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return 0, fmt.Errorf("ffmpeg loudnorm measurement pass: %w", err)
+	}
+	m, err := parseLoudnormOutput(stderr.String())
+	if err != nil {
+		return 0, err
+	}
+	lufs, err := strconv.ParseFloat(m.InputI, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bad measured loudness %q: %w", m.InputI, err)
+	}
+	if !p.quiet {
+		slog.Info("measured loudness", "input", inputFile, "lufs", lufs)
+	}
+
+	if err := p.runFFmpeg(loudnormApplyArgs(inputFile, outputFile, *m)...); err != nil {
+		return 0, fmt.Errorf("loudnorm correction pass: %w", err)
+	}
+	return lufs, nil
+}
+
+// formatSeconds renders a second count the way ffmpeg expects it.
+func formatSeconds(secs float64) string {
+	return strconv.FormatFloat(secs, 'f', 3, 64)
+}
+
+// parseTime converts "HH:MM:SS", "MM:SS", a bare number of seconds
+// (fractions allowed), or a Go-style duration such as "1h30m5s" into
+// seconds.
+func parseTime(s string) (float64, error) {
+	if strings.ContainsAny(s, "hms") {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return 0, fmt.Errorf("bad duration %q: %w", s, err)
+		}
+		if d < 0 {
+			return 0, fmt.Errorf("negative duration %q", s)
+		}
+		return d.Seconds(), nil
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("too many ':' separators")
+	}
+	total := 0.0
+	for _, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return 0, fmt.Errorf("bad component %q", part)
+		}
+		if v < 0 {
+			return 0, fmt.Errorf("negative component %q", part)
+		}
+		total = total*60 + v
+	}
+	return total, nil
+}
+
+// Processor is the interface commands drive the video processor through, so
+// tests can substitute MockVideoProcessor for the real ffmpeg-backed one.
+type Processor interface {
+	GetVideoInfo(inputFile string) (*VideoInfo, error)
+	SplitVideo(inputFile, outputDir, startTime, endTime string, chunkDuration int) error
+	SplitVideoCount(inputFile, outputDir, startTime, endTime string, numChunks int) error
+	SplitVideoFromEDL(inputFile, outputDir string, segments []Segment) error
+	SetQuiet(quiet bool)
+	SetShowProgress(show bool)
+	SetChunkNaming(startIndex, padWidth int)
+	SetNameTemplate(tmpl string) error
+	SetTimestampNames(timestamps bool)
+	SetOutputDirPolicy(policy OutputDirPolicy)
+	SetAudioEncoding(codec, bitrate string)
+	SetCopyMode(copy bool)
+	SetEmbedMetadata(embed bool)
+	SetExtraArgs(args []string)
+}
+
+// SplitCall records the arguments of one MockVideoProcessor.SplitVideo call.
+type SplitCall struct {
+	InputFile     string
+	OutputDir     string
+	StartTime     string
+	EndTime       string
+	ChunkDuration int
+}
+
+// CountCall records the arguments of one MockVideoProcessor.SplitVideoCount
+// call.
+type CountCall struct {
+	InputFile string
+	OutputDir string
+	StartTime string
+	EndTime   string
+	NumChunks int
+}
+
+// EDLCall records the arguments of one MockVideoProcessor.SplitVideoFromEDL
+// call.
+type EDLCall struct {
+	InputFile string
+	OutputDir string
+	Segments  []Segment
+}
+
+// MockVideoProcessor implements Processor without running ffmpeg, recording
+// every call so tests can assert how a command drove it.
+type MockVideoProcessor struct {
+	mu sync.Mutex
+
+	// Info is the metadata served by GetVideoInfo; a minimal placeholder is
+	// returned when nil.
+	Info *VideoInfo
+	// Err, when set, is returned from GetVideoInfo and both split methods.
+	Err error
+
+	InfoCalls      []string
+	SplitCalls     []SplitCall
+	CountCalls     []CountCall
+	EDLCalls       []EDLCall
+	Quiet          bool
+	ShowProgress   bool
+	StartIndex     int
+	PadWidth       int
+	NameTemplate   string
+	TimestampNames bool
+	OutputPolicy   OutputDirPolicy
+	AudioCodec     string
+	AudioBitrate   string
+	CopyMode       bool
+	EmbedMeta      bool
+	ExtraArgs      []string
+}
+
+// NewMockVideoProcessor creates a mock that succeeds with placeholder info.
+func NewMockVideoProcessor() *MockVideoProcessor {
+	return &MockVideoProcessor{}
+}
+
+// GetVideoInfo returns the canned Info, or a placeholder when none is set.
+func (m *MockVideoProcessor) GetVideoInfo(inputFile string) (*VideoInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.InfoCalls = append(m.InfoCalls, inputFile)
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	if m.Info != nil {
+		return m.Info, nil
+	}
+	return &VideoInfo{Path: inputFile, Width: 640, Height: 360, VideoCodec: "h264"}, nil
+}
+
+// SplitVideo records the call and returns the canned error, if any.
+func (m *MockVideoProcessor) SplitVideo(inputFile, outputDir, startTime, endTime string, chunkDuration int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.SplitCalls = append(m.SplitCalls, SplitCall{
+		InputFile:     inputFile,
+		OutputDir:     outputDir,
+		StartTime:     startTime,
+		EndTime:       endTime,
+		ChunkDuration: chunkDuration,
+	})
+	return m.Err
+}
+
+// SplitVideoCount records the call and returns the canned error, if any.
+func (m *MockVideoProcessor) SplitVideoCount(inputFile, outputDir, startTime, endTime string, numChunks int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.CountCalls = append(m.CountCalls, CountCall{
+		InputFile: inputFile,
+		OutputDir: outputDir,
+		StartTime: startTime,
+		EndTime:   endTime,
+		NumChunks: numChunks,
+	})
+	return m.Err
+}
+
+// SplitVideoFromEDL records the call and returns the canned error, if any.
+func (m *MockVideoProcessor) SplitVideoFromEDL(inputFile, outputDir string, segments []Segment) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.EDLCalls = append(m.EDLCalls, EDLCall{InputFile: inputFile, OutputDir: outputDir, Segments: segments})
+	return m.Err
+}
+
+// SetQuiet implements Processor.
+func (m *MockVideoProcessor) SetQuiet(quiet bool) {
+	m.Quiet = quiet
+}
+
+// SetShowProgress implements Processor.
+func (m *MockVideoProcessor) SetShowProgress(show bool) {
+	m.ShowProgress = show
+}
+
+// SetChunkNaming implements Processor.
+func (m *MockVideoProcessor) SetChunkNaming(startIndex, padWidth int) {
+	m.StartIndex = startIndex
+	m.PadWidth = padWidth
+}
+
+// SetNameTemplate implements Processor.
+func (m *MockVideoProcessor) SetNameTemplate(tmpl string) error {
+	m.NameTemplate = tmpl
+	return nil
+}
+
+// SetTimestampNames implements Processor.
+func (m *MockVideoProcessor) SetTimestampNames(timestamps bool) {
+	m.TimestampNames = timestamps
+}
+
+// SetOutputDirPolicy implements Processor.
+func (m *MockVideoProcessor) SetOutputDirPolicy(policy OutputDirPolicy) {
+	m.OutputPolicy = policy
+}
+
+// SetAudioEncoding implements Processor.
+func (m *MockVideoProcessor) SetAudioEncoding(codec, bitrate string) {
+	m.AudioCodec = codec
+	m.AudioBitrate = bitrate
+}
+
+// SetCopyMode implements Processor.
+func (m *MockVideoProcessor) SetCopyMode(copy bool) {
+	m.CopyMode = copy
+}
+
+// SetEmbedMetadata implements Processor.
+func (m *MockVideoProcessor) SetEmbedMetadata(embed bool) {
+	m.EmbedMeta = embed
+}
+
+// SetExtraArgs implements Processor.
+func (m *MockVideoProcessor) SetExtraArgs(args []string) {
+	m.ExtraArgs = args
+}
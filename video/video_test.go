@@ -0,0 +1,784 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestChunkFileNameHonorsOffsetAndPadding(t *testing.T) {
+	p := &VideoProcessor{}
+	if got := p.chunkFileName(1); got != "chunk_001.mp4" {
+		t.Errorf("default naming = %q, want chunk_001.mp4", got)
+	}
+
+	p.SetChunkNaming(101, 5)
+	if got := p.chunkFileName(101); got != "chunk_00101.mp4" {
+		t.Errorf("padded naming = %q, want chunk_00101.mp4", got)
+	}
+	if got := p.chunkFileName(102); got != "chunk_00102.mp4" {
+		t.Errorf("padded naming = %q, want chunk_00102.mp4", got)
+	}
+}
+
+func TestSetNameTemplateRendersVariables(t *testing.T) {
+	p := &VideoProcessor{}
+	if err := p.SetNameTemplate("{{.Base}}_{{.Index}}_{{.Start}}-{{.End}}.mp4"); err != nil {
+		t.Fatalf("SetNameTemplate: %v", err)
+	}
+	got, err := p.chunkName(ChunkNameData{Index: 7, Start: 60, End: 90, Base: "roadtrip"})
+	if err != nil {
+		t.Fatalf("chunkName: %v", err)
+	}
+	if got != "roadtrip_7_60-90.mp4" {
+		t.Errorf("chunkName = %q, want roadtrip_7_60-90.mp4", got)
+	}
+
+	if err := p.SetNameTemplate(""); err != nil {
+		t.Fatalf("SetNameTemplate reset: %v", err)
+	}
+	if got, _ := p.chunkName(ChunkNameData{Index: 7}); got != "chunk_007.mp4" {
+		t.Errorf("default chunkName = %q, want chunk_007.mp4", got)
+	}
+}
+
+func TestSetNameTemplateRejectsBadTemplates(t *testing.T) {
+	p := &VideoProcessor{}
+	if err := p.SetNameTemplate("{{.Index"); err == nil {
+		t.Error("expected an error for unterminated template syntax")
+	}
+	if err := p.SetNameTemplate("{{.NoSuchField}}.mp4"); err == nil {
+		t.Error("expected an error for an unknown template variable")
+	}
+}
+
+func TestRunFFmpegQuietSuppressesOutput(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+
+	var out bytes.Buffer
+	p := &VideoProcessor{ffmpegPath: sh, stdout: &out, stderr: &out}
+	p.SetQuiet(true)
+	if err := p.runFFmpeg("-c", "echo noisy output"); err != nil {
+		t.Fatalf("runFFmpeg: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("quiet run wrote output: %q", out.String())
+	}
+
+	if err := p.runFFmpeg("-c", "echo boom >&2; exit 1"); err == nil {
+		t.Fatal("expected an error from the failing command")
+	} else if !strings.Contains(err.Error(), "boom") {
+		t.Errorf("error %q should surface the captured output", err)
+	}
+
+	p.SetQuiet(false)
+	if err := p.runFFmpeg("-c", "echo noisy output"); err != nil {
+		t.Fatalf("runFFmpeg: %v", err)
+	}
+	if !strings.Contains(out.String(), "noisy output") {
+		t.Errorf("non-quiet run should stream output, got %q", out.String())
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{"01:30:00", 5400, false},
+		{"02:15", 135, false},
+		{"90", 90, false},
+		{"90.5", 90.5, false},
+		{"1h30m", 5400, false},
+		{"90s", 90, false},
+		{"1h30m5s", 5405, false},
+		{"-90s", 0, true},
+		{"90x", 0, true},
+		{"1:2:3:4", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTime(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseTime(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLoudnormMeasureArgs(t *testing.T) {
+	args := strings.Join(loudnormMeasureArgs("in.mp4"), " ")
+	if !strings.Contains(args, "loudnorm=I=-16:TP=-1.5:LRA=11:print_format=json") {
+		t.Errorf("measurement filter missing or wrong: %s", args)
+	}
+	if !strings.HasSuffix(args, "-f null -") {
+		t.Errorf("measurement pass should discard output: %s", args)
+	}
+}
+
+func TestLoudnormApplyArgs(t *testing.T) {
+	m := loudnormMeasurements{
+		InputI:       "-23.1",
+		InputTP:      "-4.5",
+		InputLRA:     "6.4",
+		InputThresh:  "-33.5",
+		TargetOffset: "0.3",
+	}
+	args := loudnormApplyArgs("in.mp4", "out.mp4", m)
+	joined := strings.Join(args, " ")
+	wantFilter := "loudnorm=I=-16:TP=-1.5:LRA=11" +
+		":measured_I=-23.1:measured_TP=-4.5:measured_LRA=6.4:measured_thresh=-33.5:offset=0.3:linear=true"
+	if !strings.Contains(joined, "-af "+wantFilter) {
+		t.Errorf("filter chain = %s, want %s", joined, wantFilter)
+	}
+	if !strings.Contains(joined, "-c:v copy") {
+		t.Errorf("correction pass should copy the video stream: %s", joined)
+	}
+	if args[len(args)-1] != "out.mp4" {
+		t.Errorf("last argument = %q, want the output file", args[len(args)-1])
+	}
+}
+
+func TestParseLoudnormOutput(t *testing.T) {
+	stderr := `frame=  100 fps=0.0 q=-0.0 size=N/A time=00:00:30.00
+[Parsed_loudnorm_0 @ 0x55d]
+{
+	"input_i" : "-23.10",
+	"input_tp" : "-4.51",
+	"input_lra" : "6.40",
+	"input_thresh" : "-33.52",
+	"output_i" : "-16.01",
+	"output_tp" : "-1.60",
+	"output_lra" : "5.90",
+	"output_thresh" : "-26.40",
+	"normalization_type" : "dynamic",
+	"target_offset" : "0.32"
+}
+`
+	m, err := parseLoudnormOutput(stderr)
+	if err != nil {
+		t.Fatalf("parseLoudnormOutput: %v", err)
+	}
+	if m.InputI != "-23.10" || m.TargetOffset != "0.32" {
+		t.Errorf("measurements = %+v", m)
+	}
+
+	if _, err := parseLoudnormOutput("ffmpeg version 6.0"); err == nil {
+		t.Error("expected an error when no statistics block is present")
+	}
+}
+
+func TestPrepareOutputDirPolicies(t *testing.T) {
+	newDirWithStaleChunk := func(t *testing.T) string {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "chunk_099.mp4"), []byte("stale"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	p := &VideoProcessor{}
+	if err := p.prepareOutputDir(t.TempDir()); err != nil {
+		t.Errorf("empty directory should pass the default policy: %v", err)
+	}
+
+	dir := newDirWithStaleChunk(t)
+	if err := p.prepareOutputDir(dir); err == nil {
+		t.Error("default policy should refuse a non-empty directory")
+	}
+
+	p.SetOutputDirPolicy(OutputDirOverwrite)
+	dir = newDirWithStaleChunk(t)
+	if err := p.prepareOutputDir(dir); err != nil {
+		t.Errorf("overwrite policy: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "chunk_099.mp4")); err != nil {
+		t.Error("overwrite policy should leave existing files in place")
+	}
+
+	p.SetOutputDirPolicy(OutputDirClean)
+	dir = newDirWithStaleChunk(t)
+	if err := p.prepareOutputDir(dir); err != nil {
+		t.Errorf("clean policy: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "chunk_099.mp4")); !os.IsNotExist(err) {
+		t.Error("clean policy should remove stale chunks")
+	}
+}
+
+func TestBatchVideoInfoProbesAllFiles(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	ffprobe := filepath.Join(dir, "ffprobe")
+	script := `#!/bin/sh
+echo '{"format": {"duration": "30.0", "size": "100"}, "streams": [{"codec_type": "video", "codec_name": "h264", "width": 640, "height": 360}]}'
+`
+	if err := os.WriteFile(ffprobe, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for i := 1; i <= 5; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("chunk_%03d.mp4", i))
+		if err := os.WriteFile(path, []byte("video"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	p := &VideoProcessor{ffprobePath: ffprobe}
+	infos, err := p.BatchVideoInfo(context.Background(), paths, 3)
+	if err != nil {
+		t.Fatalf("BatchVideoInfo: %v", err)
+	}
+	if len(infos) != len(paths) {
+		t.Fatalf("got %d infos, want %d", len(infos), len(paths))
+	}
+	for _, path := range paths {
+		info := infos[path]
+		if info == nil {
+			t.Errorf("no info for %s", path)
+			continue
+		}
+		if info.Path != path || info.Width != 640 || info.VideoCodec != "h264" {
+			t.Errorf("info for %s = %+v", path, info)
+		}
+	}
+}
+
+func TestBatchVideoInfoCollectsPerFileErrors(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	ffprobe := filepath.Join(dir, "ffprobe")
+	script := "#!/bin/sh\necho '{\"format\": {\"duration\": \"30.0\"}}'\n"
+	if err := os.WriteFile(ffprobe, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	good := filepath.Join(dir, "good.mp4")
+	if err := os.WriteFile(good, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.mp4")
+
+	p := &VideoProcessor{ffprobePath: ffprobe}
+	infos, err := p.BatchVideoInfo(context.Background(), []string{good, missing}, 2)
+	if err == nil {
+		t.Error("expected an error for the missing file")
+	}
+	if len(infos) != 1 || infos[good] == nil {
+		t.Errorf("infos = %v, want only the good file", infos)
+	}
+}
+
+func TestExtractChunkArgsAudioHandling(t *testing.T) {
+	p := &VideoProcessor{}
+	args := strings.Join(p.extractChunkArgs("in.mp4", "out.mp4", 1, 0, 30), " ")
+	if !strings.Contains(args, "-c:a copy") {
+		t.Errorf("default args should copy the audio stream: %s", args)
+	}
+
+	p.SetAudioEncoding("aac", "192k")
+	args = strings.Join(p.extractChunkArgs("in.mp4", "out.mp4", 1, 0, 30), " ")
+	if !strings.Contains(args, "-c:a aac -b:a 192k") {
+		t.Errorf("re-encode args missing: %s", args)
+	}
+	if strings.Contains(args, "-c:a copy") {
+		t.Errorf("re-encode args should not also copy: %s", args)
+	}
+
+	p.SetAudioEncoding("", "")
+	args = strings.Join(p.extractChunkArgs("in.mp4", "out.mp4", 1, 0, 30), " ")
+	if !strings.Contains(args, "-c:a copy") {
+		t.Errorf("reset should restore the copy behavior: %s", args)
+	}
+}
+
+func TestParseEDL(t *testing.T) {
+	input := `# interesting bits
+00:30,01:00,opening act
+90,120
+2:00,2:30, closing act
+`
+	segments, err := ParseEDL(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseEDL: %v", err)
+	}
+	want := []Segment{
+		{Start: 30, End: 60, Label: "opening act"},
+		{Start: 90, End: 120},
+		{Start: 120, End: 150, Label: "closing act"},
+	}
+	if len(segments) != len(want) {
+		t.Fatalf("got %d segments, want %d", len(segments), len(want))
+	}
+	for i, segment := range segments {
+		if segment != want[i] {
+			t.Errorf("segment %d = %+v, want %+v", i, segment, want[i])
+		}
+	}
+
+	for _, bad := range []string{
+		"",
+		"only-one-field\n",
+		"xx,30\n",
+		"60,30\n",
+	} {
+		if _, err := ParseEDL(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseEDL(%q) should fail", bad)
+		}
+	}
+}
+
+func TestSplitVideoFromEDLExtractCalls(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	argsLog := filepath.Join(dir, "args.log")
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" >> %s\n", argsLog)
+	if err := os.WriteFile(ffmpeg, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &VideoProcessor{ffmpegPath: ffmpeg}
+	p.SetQuiet(true)
+	outputDir := filepath.Join(dir, "out")
+	segments := []Segment{
+		{Start: 30, End: 60, Label: "opening act"},
+		{Start: 90, End: 120},
+	}
+	if err := p.SplitVideoFromEDL("roadtrip.mp4", outputDir, segments); err != nil {
+		t.Fatalf("SplitVideoFromEDL: %v", err)
+	}
+
+	data, err := os.ReadFile(argsLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) != len(segments) {
+		t.Fatalf("got %d ffmpeg calls, want %d", len(lines), len(segments))
+	}
+	first := filepath.Join(outputDir, "chunk_001_opening_act.mp4")
+	if !strings.Contains(lines[0], "-ss 30.00") || !strings.Contains(lines[0], "-t 30.00") || !strings.Contains(lines[0], first) {
+		t.Errorf("first call = %q, want -ss 30.00 -t 30.00 writing %s", lines[0], first)
+	}
+	second := filepath.Join(outputDir, "chunk_002.mp4")
+	if !strings.Contains(lines[1], "-ss 90.00") || !strings.Contains(lines[1], second) {
+		t.Errorf("second call = %q, want -ss 90.00 writing %s", lines[1], second)
+	}
+}
+
+func TestGetVideoInfoRetriesTransientProbeFailure(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	input := filepath.Join(dir, "roadtrip.mp4")
+	if err := os.WriteFile(input, []byte("fake video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	marker := filepath.Join(dir, "failed-once")
+	ffprobe := filepath.Join(dir, "ffprobe")
+	script := fmt.Sprintf(`#!/bin/sh
+if [ ! -f %s ]; then
+  touch %s
+  exit 1
+fi
+echo '{"format": {"duration": "30.0", "size": "100"}, "streams": [{"codec_type": "video", "codec_name": "h264", "width": 640, "height": 360}]}'
+`, marker, marker)
+	if err := os.WriteFile(ffprobe, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &VideoProcessor{ffprobePath: ffprobe}
+	if _, err := p.GetVideoInfo(input); err == nil {
+		t.Fatal("default single attempt should surface the transient failure")
+	}
+
+	if err := os.Remove(marker); err != nil {
+		t.Fatal(err)
+	}
+	p.SetProbeRetries(2, 0)
+	info, err := p.GetVideoInfo(input)
+	if err != nil {
+		t.Fatalf("GetVideoInfo with retries: %v", err)
+	}
+	if info.Width != 640 || info.VideoCodec != "h264" {
+		t.Errorf("info = %+v, want width 640 codec h264", info)
+	}
+}
+
+func TestSnapToKeyframe(t *testing.T) {
+	raw := []byte(`{"frames": [
+		{"pts_time": "0.000000"},
+		{"pts_time": "2.500000"},
+		{"pts_time": "5.000000"},
+		{"pts_time": "10.000000"}
+	]}`)
+	keyframes, err := parseKeyframes(raw)
+	if err != nil {
+		t.Fatalf("parseKeyframes: %v", err)
+	}
+	want := []float64{0, 2.5, 5, 10}
+	if len(keyframes) != len(want) {
+		t.Fatalf("got %d keyframes, want %d", len(keyframes), len(want))
+	}
+	for i, kf := range keyframes {
+		if kf != want[i] {
+			t.Errorf("keyframe %d = %v, want %v", i, kf, want[i])
+		}
+	}
+
+	for _, tc := range []struct {
+		t, want float64
+	}{
+		{0, 0},     // exact keyframe is kept
+		{1.2, 0},   // snaps back, not forward
+		{2.5, 2.5}, // exact keyframe is kept
+		{4.9, 2.5}, // nearest prior, even when 5.0 is closer
+		{7, 5},     // mid-GOP snaps to the preceding keyframe
+		{30, 10},   // past the last keyframe
+		{-1, -1},   // before the first keyframe stays put
+	} {
+		if got := snapToKeyframe(keyframes, tc.t); got != tc.want {
+			t.Errorf("snapToKeyframe(%v) = %v, want %v", tc.t, got, tc.want)
+		}
+	}
+
+	if _, err := parseKeyframes([]byte(`{"frames": []}`)); err == nil {
+		t.Error("parseKeyframes should fail with no keyframes")
+	}
+	if _, err := parseKeyframes([]byte(`not json`)); err == nil {
+		t.Error("parseKeyframes should fail on malformed output")
+	}
+}
+
+func TestExtractChunkArgsEmbedMetadata(t *testing.T) {
+	p := &VideoProcessor{}
+	args := strings.Join(p.extractChunkArgs("roadtrip.mp4", "out/chunk_002.mp4", 2, 30, 30), " ")
+	if strings.Contains(args, "-metadata") {
+		t.Errorf("metadata should be off by default, got %q", args)
+	}
+
+	p.SetEmbedMetadata(true)
+	args = strings.Join(p.extractChunkArgs("roadtrip.mp4", "out/chunk_002.mp4", 2, 30, 30), " ")
+	for _, want := range []string{
+		"-metadata title=chunk_002",
+		"-metadata comment=roadtrip.mp4 30.000-60.000",
+		"-metadata track=2",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args %q missing %q", args, want)
+		}
+	}
+
+	args = strings.Join(p.extractChunkArgs("roadtrip.mp4", "out/chunk_003.mp4", 3, 60, 30), " ")
+	for _, want := range []string{
+		"-metadata title=chunk_003",
+		"-metadata comment=roadtrip.mp4 60.000-90.000",
+		"-metadata track=3",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("args %q missing %q", args, want)
+		}
+	}
+}
+
+func TestSplitVideoMultiResScalesEachRendition(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	input := filepath.Join(dir, "clip.mp4")
+	if err := os.WriteFile(input, []byte("fake video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ffprobe := filepath.Join(dir, "ffprobe")
+	probeScript := `#!/bin/sh
+echo '{"format": {"duration": "30.0", "size": "100"}, "streams": [{"codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080}]}'
+`
+	if err := os.WriteFile(ffprobe, []byte(probeScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	argsLog := filepath.Join(dir, "args.log")
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" >> %s\n", argsLog)
+	if err := os.WriteFile(ffmpeg, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &VideoProcessor{ffmpegPath: ffmpeg, ffprobePath: ffprobe}
+	p.SetQuiet(true)
+	outputDir := filepath.Join(dir, "out")
+	renditions := []Rendition{
+		{Label: "720p", Width: 1280, Height: 720, Bitrate: "2500k"},
+		{Label: "360p", Width: 640, Height: 360, Bitrate: "800k"},
+	}
+	if err := p.SplitVideoMultiRes(input, outputDir, renditions); err != nil {
+		t.Fatalf("SplitVideoMultiRes: %v", err)
+	}
+
+	data, err := os.ReadFile(argsLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) != len(renditions) {
+		t.Fatalf("got %d ffmpeg calls, want %d", len(lines), len(renditions))
+	}
+	if want := "-vf scale=1280:720 -b:v 2500k"; !strings.Contains(lines[0], want) {
+		t.Errorf("720p call %q missing %q", lines[0], want)
+	}
+	if want := filepath.Join(outputDir, "chunk_001_720p.mp4"); !strings.Contains(lines[0], want) {
+		t.Errorf("720p call %q should write %s", lines[0], want)
+	}
+	if want := "-vf scale=640:360 -b:v 800k"; !strings.Contains(lines[1], want) {
+		t.Errorf("360p call %q missing %q", lines[1], want)
+	}
+	if want := filepath.Join(outputDir, "chunk_001_360p.mp4"); !strings.Contains(lines[1], want) {
+		t.Errorf("360p call %q should write %s", lines[1], want)
+	}
+
+	if err := p.SplitVideoMultiRes(input, outputDir, nil); err == nil {
+		t.Error("expected an error with no renditions")
+	}
+	if err := p.SplitVideoMultiRes(input, filepath.Join(dir, "out2"), []Rendition{{Width: 640, Height: 360}}); err == nil {
+		t.Error("expected an error for a rendition without a label")
+	}
+}
+
+func TestTimestampNamesEncodeChunkStart(t *testing.T) {
+	p := &VideoProcessor{}
+	p.SetTimestampNames(true)
+
+	tests := []struct {
+		start float64
+		want  string
+	}{
+		{0, "clip_00-00-00.mp4"},
+		{90, "clip_00-01-30.mp4"},
+		{3725, "clip_01-02-05.mp4"},
+	}
+	seen := make(map[string]bool)
+	for _, tt := range tests {
+		got, err := p.chunkName(ChunkNameData{Index: 1, Start: tt.start, Base: "clip"})
+		if err != nil {
+			t.Fatalf("chunkName(start=%v): %v", tt.start, err)
+		}
+		if got != tt.want {
+			t.Errorf("chunkName(start=%v) = %q, want %q", tt.start, got, tt.want)
+		}
+		if seen[got] {
+			t.Errorf("name %q not unique across chunks", got)
+		}
+		seen[got] = true
+	}
+
+	// A custom template still wins over the timestamp preset.
+	if err := p.SetNameTemplate("{{.Base}}_{{.Index}}.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := p.chunkName(ChunkNameData{Index: 7, Start: 90, Base: "clip"}); got != "clip_7.mp4" {
+		t.Errorf("templated name = %q, want clip_7.mp4", got)
+	}
+}
+
+func TestVideoWithoutAudioStream(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	ffprobe := filepath.Join(dir, "ffprobe")
+	script := `#!/bin/sh
+echo '{"format": {"duration": "30.0", "size": "100"}, "streams": [{"codec_type": "video", "codec_name": "h264", "width": 640, "height": 360}]}'
+`
+	if err := os.WriteFile(ffprobe, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(dir, "silent.mp4")
+	if err := os.WriteFile(input, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &VideoProcessor{ffprobePath: ffprobe}
+	info, err := p.GetVideoInfo(input)
+	if err != nil {
+		t.Fatalf("GetVideoInfo: %v", err)
+	}
+	if info.HasAudio {
+		t.Error("HasAudio = true for a video-only file")
+	}
+	if info.AudioCodec != "" {
+		t.Errorf("AudioCodec = %q, want empty", info.AudioCodec)
+	}
+
+	err = p.ExtractAudio(input, filepath.Join(dir, "out.m4a"))
+	if !errors.Is(err, ErrNoAudioStream) {
+		t.Errorf("ExtractAudio error = %v, want ErrNoAudioStream", err)
+	}
+
+	withAudio := `#!/bin/sh
+echo '{"format": {"duration": "30.0", "size": "100"}, "streams": [{"codec_type": "video", "codec_name": "h264", "width": 640, "height": 360}, {"codec_type": "audio", "codec_name": "aac"}]}'
+`
+	if err := os.WriteFile(ffprobe, []byte(withAudio), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	info, err = p.GetVideoInfo(input)
+	if err != nil {
+		t.Fatalf("GetVideoInfo: %v", err)
+	}
+	if !info.HasAudio || info.AudioCodec != "aac" {
+		t.Errorf("info = %+v, want HasAudio with aac", info)
+	}
+}
+
+func TestSplitVideoCountYieldsEqualChunks(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	argsLog := filepath.Join(dir, "args.log")
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" >> %s\n", argsLog)
+	if err := os.WriteFile(ffmpeg, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &VideoProcessor{ffmpegPath: ffmpeg}
+	p.SetQuiet(true)
+	outputDir := filepath.Join(dir, "out")
+	if err := p.SplitVideoCount("roadtrip.mp4", outputDir, "0", "60", 4); err != nil {
+		t.Fatalf("SplitVideoCount: %v", err)
+	}
+
+	data, err := os.ReadFile(argsLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("got %d ffmpeg calls, want 4", len(lines))
+	}
+	for i, line := range lines {
+		wantStart := fmt.Sprintf("-ss %.3f", float64(i)*15)
+		name := filepath.Join(outputDir, fmt.Sprintf("chunk_%03d.mp4", i+1))
+		if !strings.Contains(line, wantStart) || !strings.Contains(line, "-t 15.000") || !strings.Contains(line, name) {
+			t.Errorf("call %d = %q, want %s -t 15.000 writing %s", i+1, line, wantStart, name)
+		}
+	}
+
+	if err := p.SplitVideoCount("roadtrip.mp4", filepath.Join(dir, "out2"), "0", "60", 0); err == nil {
+		t.Error("expected an error for a non-positive chunk count")
+	}
+	if err := p.SplitVideoCount("roadtrip.mp4", filepath.Join(dir, "out3"), "60", "60", 4); err == nil {
+		t.Error("expected an error for an empty span")
+	}
+}
+
+func TestRemuxCopiesCompatibleStreamsAndTranscodesOthers(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	argsLog := filepath.Join(dir, "args.log")
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	if err := os.WriteFile(ffmpeg, []byte(fmt.Sprintf("#!/bin/sh\necho \"$@\" >> %s\n", argsLog)), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	ffprobe := filepath.Join(dir, "ffprobe")
+	compatible := `#!/bin/sh
+echo '{"format": {"duration": "30.0", "size": "100"}, "streams": [{"codec_type": "video", "codec_name": "h264", "width": 640, "height": 360}, {"codec_type": "audio", "codec_name": "aac"}]}'
+`
+	if err := os.WriteFile(ffprobe, []byte(compatible), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(dir, "clip.mov")
+	if err := os.WriteFile(input, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &VideoProcessor{ffmpegPath: ffmpeg, ffprobePath: ffprobe}
+	p.SetQuiet(true)
+	copied, err := p.Remux(input, filepath.Join(dir, "clip.mp4"))
+	if err != nil {
+		t.Fatalf("Remux: %v", err)
+	}
+	if !copied {
+		t.Error("compatible codecs should be remuxed, not transcoded")
+	}
+	data, err := os.ReadFile(argsLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "-c copy") || strings.Contains(string(data), "libx264") {
+		t.Errorf("remux call = %q, want a stream copy", string(data))
+	}
+
+	incompatible := `#!/bin/sh
+echo '{"format": {"duration": "30.0", "size": "100"}, "streams": [{"codec_type": "video", "codec_name": "mpeg2video", "width": 640, "height": 360}, {"codec_type": "audio", "codec_name": "pcm_s16le"}]}'
+`
+	if err := os.WriteFile(ffprobe, []byte(incompatible), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(argsLog); err != nil {
+		t.Fatal(err)
+	}
+	copied, err = p.Remux(input, filepath.Join(dir, "clip2.mp4"))
+	if err != nil {
+		t.Fatalf("Remux: %v", err)
+	}
+	if copied {
+		t.Error("incompatible codecs should be transcoded")
+	}
+	data, err = os.ReadFile(argsLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "-c:v libx264") || !strings.Contains(string(data), "-c:a aac") {
+		t.Errorf("transcode call = %q, want libx264 video and aac audio", string(data))
+	}
+}
+
+func TestSetExtraArgsAppendedBeforeOutput(t *testing.T) {
+	p := &VideoProcessor{}
+	args := p.extractChunkArgs("in.mp4", "out.mp4", 1, 0, 30)
+	baseline := len(args)
+	if args[baseline-1] != "out.mp4" {
+		t.Fatalf("last argument = %q, want the output file", args[baseline-1])
+	}
+
+	p.SetExtraArgs([]string{"-movflags", "+faststart", "-preset", "veryslow"})
+	args = p.extractChunkArgs("in.mp4", "out.mp4", 1, 0, 30)
+	if len(args) != baseline+4 {
+		t.Fatalf("got %d arguments, want %d", len(args), baseline+4)
+	}
+	if args[len(args)-1] != "out.mp4" {
+		t.Errorf("last argument = %q, extra args must stay before the output file", args[len(args)-1])
+	}
+	got := strings.Join(args[len(args)-5:len(args)-1], " ")
+	if got != "-movflags +faststart -preset veryslow" {
+		t.Errorf("args before output = %q, want the extra args verbatim", got)
+	}
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "-preset fast ") || strings.Index(joined, "-preset fast") > strings.Index(joined, "-preset veryslow") {
+		t.Errorf("extra args should follow the built-ins so they win: %q", joined)
+	}
+}